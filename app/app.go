@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -18,40 +20,177 @@ func Run(makeDb func(dbfile string) Db) {
 	log.Print("")
 	benchmarks := "simple,real,complex,many,large,concurrent"
 	flag.StringVar(&benchmarks, "benchmarks", benchmarks, "specify benchmarks to run, comma separated")
+	flag.BoolVar(&cpuProfile, "cpuprofile", cpuProfile, "write a cpu profile for each timed section")
+	flag.BoolVar(&memProfile, "memprofile", memProfile, "write a heap profile for each timed section")
+	flag.BoolVar(&blockProfile, "blockprofile", blockProfile, "write a goroutine blocking profile for each timed section")
+	flag.BoolVar(&traceProfile, "trace", traceProfile, "write an execution trace for each timed section")
+	flag.StringVar(&profileDir, "profiledir", profileDir, "specify the output directory for profiles and traces")
+	pragmaProfile := "default"
+	flag.StringVar(&pragmaProfile, "pragmas", pragmaProfile, "specify pragma profile, one of "+strings.Join(pragmaProfileNames(), ","))
+	pragmaOverrides := make(map[string]string)
+	for _, name := range tunablePragmas {
+		flag.Func(name, "override PRAGMA "+name+" of the pragma profile", func(value string) error {
+			pragmaOverrides[name] = value
+			return nil
+		})
+	}
+	target := targetFile
+	flag.StringVar(&target, "target", target, "specify database target, one of file,memory,shared,tmpfs")
+	tmpfsDir := "/dev/shm"
+	flag.StringVar(&tmpfsDir, "tmpfsdir", tmpfsDir, "specify the tmpfs directory for target tmpfs")
+	writerSpec := ""
+	flag.StringVar(&writerSpec, "writer", writerSpec, "run as writer process of the procs benchmark (internal)")
+	maxOpens := "0"
+	flag.StringVar(&maxOpens, "maxopen", maxOpens, "specify database/sql max open connections, 0 is unlimited, comma separated values (or gomaxprocs) run all benchmarks once per value")
+	flag.IntVar(&poolConfig.MaxIdleConns, "maxidle", poolConfig.MaxIdleConns, "specify database/sql max idle connections")
+	flag.DurationVar(&poolConfig.ConnMaxLifetime, "maxlifetime", poolConfig.ConnMaxLifetime, "specify database/sql max connection lifetime, 0 is forever")
+	flag.StringVar(&dataset, "dataset", dataset, "specify dataset, one of regular,realistic")
+	flag.Uint64Var(&dataSeed, "seed", dataSeed, "specify the random seed of the realistic dataset")
+	flag.IntVar(&backupStep, "backupstep", backupStep, "specify the pages per step of the backup benchmark, -1 copies all pages in one step")
+	flag.Float64Var(&upsertHits, "upserthits", upsertHits, "specify the ratio of upserts of existing users in the upsert benchmark, from 0 to 1")
+	flag.IntVar(&blobChunk, "blobchunk", blobChunk, "specify the bytes per incremental read and write of the blob benchmark")
+	jsonDepthsSpec := "1,8"
+	flag.StringVar(&jsonDepthsSpec, "jsondepths", jsonDepthsSpec, "specify the nesting levels of the JSON profiles of the json benchmark, comma separated")
+	pageSizesSpec := "50,1000"
+	flag.StringVar(&pageSizesSpec, "pagesizes", pageSizesSpec, "specify the rows per page of the paginate benchmark, comma separated")
+	flag.DurationVar(&benchDuration, "duration", benchDuration, "repeat each timed operation for this long and report ops/s and ns/op, 0 runs it once")
+	flag.BoolVar(&verifyMode, "verify", verifyMode, "run all benchmarks at tiny scale with concurrency checks, build with -race, results are meaningless")
 	flag.Parse()
-	dbfile := flag.Arg(0)
-	if dbfile == "" {
-		log.Fatal("dbfile empty, cannot bench")
-	}
+	applyPragmaProfile(pragmaProfile, pragmaOverrides)
+	checkDataset()
+	checkUpsertHits()
+	checkBlobChunk()
+	maxOpenValues := parseMaxOpens(maxOpens)
+	jsonDepths = parseJsonDepths(jsonDepthsSpec)
+	pageSizes = parsePageSizes(pageSizesSpec)
+	dbfile := targetDbfile(target, flag.Arg(0), tmpfsDir)
 	// verbose
 	if verbose {
 		log.Printf("benchmarks %q", benchmarks)
 		log.Printf("dbfile %q", dbfile)
 	}
-	// run selected benchmarks
+	if writerSpec != "" {
+		runWriter(dbfile, writerSpec, makeDb)
+		return
+	}
+	if verifyMode {
+		benchmarks = allBenchmarks
+		makeDb = verifyMakeDb(makeDb)
+	}
+	// document environment
+	logEnvironment(dbfile, makeDb)
+	// run selected benchmarks, once per pool size
+	for _, maxOpen := range maxOpenValues {
+		poolConfig.MaxOpenConns = maxOpen
+		if len(maxOpenValues) > 1 {
+			log.Printf("pool - maxopen=%d maxidle=%d maxlifetime=%s", poolConfig.MaxOpenConns, poolConfig.MaxIdleConns, poolConfig.ConnMaxLifetime)
+		}
+		runBenchmarks(benchmarks, dbfile, makeDb)
+	}
+	if verifyMode {
+		log.Printf("verify - ok")
+	}
+}
+
+// parseMaxOpens parses the -maxopen flag.
+func parseMaxOpens(maxOpens string) []int {
+	var values []int
+	for _, s := range strings.Split(maxOpens, ",") {
+		if s == "gomaxprocs" {
+			values = append(values, runtime.GOMAXPROCS(0))
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			log.Fatalf("invalid maxopen %q", s)
+		}
+		values = append(values, n)
+	}
+	return values
+}
+
+func runBenchmarks(benchmarks string, dbfile string, makeDb func(dbfile string) Db) {
+	t := &timing{}
 	if strings.Contains(benchmarks, "simple") {
-		benchSimple(dbfile, makeDb)
+		benchSimple(t, dbfile, makeDb)
 	}
 	if strings.Contains(benchmarks, "real") {
-		benchReal(dbfile, makeDb)
+		benchReal(t, dbfile, makeDb)
 	}
 	if strings.Contains(benchmarks, "complex") {
-		benchComplex(dbfile, makeDb)
+		benchComplex(t, dbfile, makeDb)
 	}
 	if strings.Contains(benchmarks, "many") {
-		benchMany(dbfile, 10, makeDb)
-		benchMany(dbfile, 100, makeDb)
-		benchMany(dbfile, 1_000, makeDb)
+		benchMany(t, dbfile, 10, makeDb)
+		benchMany(t, dbfile, 100, makeDb)
+		benchMany(t, dbfile, 1_000, makeDb)
 	}
 	if strings.Contains(benchmarks, "large") {
-		benchLarge(dbfile, 50_000, makeDb)
-		benchLarge(dbfile, 100_000, makeDb)
-		benchLarge(dbfile, 200_000, makeDb)
+		benchLarge(t, dbfile, 50_000, makeDb)
+		benchLarge(t, dbfile, 100_000, makeDb)
+		benchLarge(t, dbfile, 200_000, makeDb)
 	}
 	if strings.Contains(benchmarks, "concurrent") {
-		benchConcurrent(dbfile, 2, makeDb)
-		benchConcurrent(dbfile, 4, makeDb)
-		benchConcurrent(dbfile, 8, makeDb)
+		benchConcurrent(t, dbfile, 2, makeDb)
+		benchConcurrent(t, dbfile, 4, makeDb)
+		benchConcurrent(t, dbfile, 8, makeDb)
+	}
+	if strings.Contains(benchmarks, "json") {
+		for _, depth := range jsonDepths {
+			benchJson(t, dbfile, depth, makeDb)
+		}
+	}
+	if strings.Contains(benchmarks, "funcs") {
+		benchFuncs(t, dbfile, makeDb)
+	}
+	if strings.Contains(benchmarks, "stream") {
+		benchStreamSimple(t, dbfile, makeDb)
+		benchStreamLarge(t, dbfile, 50_000, makeDb)
+		benchStreamLarge(t, dbfile, 100_000, makeDb)
+		benchStreamLarge(t, dbfile, 200_000, makeDb)
+	}
+	if strings.Contains(benchmarks, "cancel") {
+		benchCancel(t, dbfile, makeDb)
+	}
+	if strings.Contains(benchmarks, "durable") {
+		benchDurable(t, dbfile, makeDb)
+	}
+	if strings.Contains(benchmarks, "pool") {
+		benchPool(t, dbfile, 2, makeDb)
+		benchPool(t, dbfile, 4, makeDb)
+		benchPool(t, dbfile, 8, makeDb)
+	}
+	if strings.Contains(benchmarks, "backup") {
+		benchBackup(t, dbfile, makeDb)
+	}
+	if strings.Contains(benchmarks, "migrate") {
+		benchMigrate(t, dbfile, "DELETE", makeDb)
+		benchMigrate(t, dbfile, "WAL", makeDb)
+	}
+	if strings.Contains(benchmarks, "bulk") {
+		benchBulk(t, dbfile, makeDb)
+	}
+	if strings.Contains(benchmarks, "upsert") {
+		benchUpsert(t, dbfile, makeDb)
+	}
+	if strings.Contains(benchmarks, "fkeys") {
+		benchFkeys(t, dbfile, makeDb)
+	}
+	if strings.Contains(benchmarks, "blob") {
+		benchBlob(t, dbfile, makeDb)
+	}
+	if strings.Contains(benchmarks, "attach") {
+		benchAttach(t, dbfile, makeDb)
+	}
+	if strings.Contains(benchmarks, "paginate") {
+		benchPaginate(t, dbfile, makeDb)
+	}
+	if strings.Contains(benchmarks, "procs") {
+		for _, journalMode := range []string{"DELETE", "WAL"} {
+			benchProcs(dbfile, journalMode, 2, makeDb)
+			benchProcs(dbfile, journalMode, 4, makeDb)
+			benchProcs(dbfile, journalMode, 8, makeDb)
+		}
 	}
 }
 
@@ -60,6 +199,7 @@ const insertArticleSql = "INSERT INTO articles(id,created,userId,text) VALUES(?,
 const insertCommentSql = "INSERT INTO comments(id,created,articleId,text) VALUES(?,?,?,?)"
 
 func initSchema(db Db) {
+	db.Exec(tunedPragmas...)
 	db.Exec(
 		"PRAGMA journal_mode=DELETE",
 		"PRAGMA synchronous=FULL",
@@ -90,116 +230,148 @@ func initSchema(db Db) {
 
 // Insert 1 million user rows in one database transaction.
 // Then query all users once.
-func benchSimple(dbfile string, makeDb func(dbfile string) Db) {
+func benchSimple(t *timing, dbfile string, makeDb func(dbfile string) Db) {
 	removeDbfiles(dbfile)
 	db := makeDb(dbfile)
 	defer db.Close()
 	initSchema(db)
 	// insert users
-	var users []User
-	base := time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local)
-	const nusers = 1_000_000
-	for i := range nusers {
-		users = append(users, NewUser(
-			i+1,                                      // id,
-			base.Add(time.Duration(i)*time.Minute),   // created,
-			fmt.Sprintf("user%08d@example.com", i+1), // email,
-			true,                                     // active,
-		))
-	}
-	t0 := time.Now()
-	db.Begin()
-	db.InsertUsers(insertUserSql, users)
-	db.Commit()
-	insertMillis := millisSince(t0)
+	nusers := scaled(1_000_000)
+	users := makeSimpleUsers(nusers)
+	want := users
+	stopProfiles := startProfiles("simple.insert", db.DriverName())
+	insert := t.measure("simple.insert", func() {
+		db.Begin()
+		db.InsertUsers(insertUserSql, users)
+		db.Commit()
+	}, func() {
+		db.Exec("DELETE FROM users")
+	})
+	stopProfiles()
 	if verbose {
-		log.Printf("  insert took %d ms", insertMillis)
+		log.Printf("  insert took %d ms", insert.millis())
 	}
 	// query users
-	t0 = time.Now()
-	users = db.FindUsers("SELECT id,created,email,active FROM users ORDER BY id")
-	MustBeEqual(len(users), nusers)
-	queryMillis := millisSince(t0)
+	stopProfiles = startProfiles("simple.query", db.DriverName())
+	query := t.measure("simple.query", func() {
+		users = db.FindUsers("SELECT id,created,email,active FROM users ORDER BY id")
+		MustBeEqual(len(users), nusers)
+	}, nil)
+	stopProfiles()
 	if verbose {
-		log.Printf("  query took %d ms", queryMillis)
+		log.Printf("  query took %d ms", query.millis())
 	}
 	// validate query result
 	for i, u := range users {
-		MustBeEqual(i+1, u.Id)
-		MustBeEqual(base.Add(time.Duration(i)*time.Minute), u.Created)
-		MustBeEqual(fmt.Sprintf("user%08d@example.com", i+1), u.Email)
-		MustBeEqual(true, u.Active)
+		MustBeEqual(want[i], u)
 	}
 	// print results
 	bench := "1_simple"
-	log.Printf("%s - insert - %-10s - %10d", bench, db.DriverName(), insertMillis)
-	log.Printf("%s - query  - %-10s - %10d", bench, db.DriverName(), queryMillis)
-	log.Printf("%s - dbsize - %-10s - %10d", bench, db.DriverName(), dbsize(dbfile))
+	logResult(bench, "insert", db.DriverName(), insert)
+	logResult(bench, "query", db.DriverName(), query)
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
+
+// makeSimpleUsers makes the users of benchSimple.
+func makeSimpleUsers(nusers int) []User {
+	g := newGenerator()
+	var users []User
+	created := g.Clock(time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local), time.Minute)
+	for i := range nusers {
+		users = append(users, NewUser(
+			i+1,                                  // id,
+			created(),                            // created,
+			g.Email("user%08d@example.com", i+1), // email,
+			true,                                 // active,
+		))
+	}
+	return users
 }
 
 // Insert 100 user with 20 articles per user and 20 comments per article.
 // Each user insert executes in a separate transaction.
 // Then query each user by email, and left-join articles and comments.
 // This benchmark is used to simulate a real-world use case.
-func benchReal(dbfile string, makeDb func(dbfile string) Db) {
+func benchReal(t *timing, dbfile string, makeDb func(dbfile string) Db) {
 	removeDbfiles(dbfile)
 	db := makeDb(dbfile)
 	defer db.Close()
 	initSchema(db)
 	// insert users with articles and comments
+	g := newGenerator()
 	base := time.Date(2025, 8, 17, 0, 0, 0, 0, time.Local)
-	created := base
-	const nusers = 100
-	const narticlesPerUser = 20
-	const ncommentsPerArticle = 20
+	created := g.Clock(base, time.Second)
+	nusers := scaled(100)
+	narticlesPerUser := scaled(20)
+	ncommentsPerArticle := scaled(20)
 	var emails []string
 	for iuser := range nusers {
-		email := fmt.Sprintf("user%08d@example.com", iuser+1)
+		email := g.Email("user%08d@example.com", iuser+1)
 		emails = append(emails, email)
 	}
 	MustBeEqual(nusers, len(emails))
-	t0 := time.Now()
-	var userId int
-	var articleId int
-	var commentId int
-	for _, email := range emails {
-		db.Begin()
-		userId++
-		user := NewUser(
-			userId,  // id,
-			created, // created,
-			email,   // email,
-			true,    // active,
-		)
-		db.InsertUsers(insertUserSql, []User{user})
-		created = created.Add(time.Second)
-		for range narticlesPerUser {
-			articleId++
-			article := NewArticle(
-				articleId, // id,
-				created,   // created,
-				userId,    // userId,
-				"text text text text text text text text text text text text", // text,
+	narticles := g.Counts(nusers, narticlesPerUser)                     // for each user
+	ncomments := g.Counts(nusers*narticlesPerUser, ncommentsPerArticle) // for each article
+	var articleTexts []string
+	for range nusers * narticlesPerUser {
+		articleTexts = append(articleTexts, g.Text("text text text text text text text text text text text text"))
+	}
+	var commentTexts []string
+	ncommentsPerUser := make([]int, nusers)
+	var iarticle int
+	for iuser := range nusers {
+		for range narticles[iuser] {
+			for range ncomments[iarticle] {
+				commentTexts = append(commentTexts, g.Text("text text text text text text text text text text text text"))
+			}
+			ncommentsPerUser[iuser] += ncomments[iarticle]
+			iarticle++
+		}
+	}
+	stopProfiles := startProfiles("real.insert", db.DriverName())
+	insert := t.measure("real.insert", func() {
+		var userId int
+		var articleId int
+		var commentId int
+		for iuser, email := range emails {
+			db.Begin()
+			userId++
+			user := NewUser(
+				userId,    // id,
+				created(), // created,
+				email,     // email,
+				true,      // active,
 			)
-			db.InsertArticles(insertArticleSql, []Article{article})
-			created = created.Add(time.Second)
-			for range ncommentsPerArticle {
-				commentId++
-				comment := NewComment(
-					commentId, // id,
-					created,   // created,
-					articleId, // articleId,
-					"text text text text text text text text text text text text", // text,
+			db.InsertUsers(insertUserSql, []User{user})
+			for range narticles[iuser] {
+				articleId++
+				article := NewArticle(
+					articleId,                 // id,
+					created(),                 // created,
+					userId,                    // userId,
+					articleTexts[articleId-1], // text,
 				)
-				db.InsertComments(insertCommentSql, []Comment{comment})
-				created = created.Add(time.Second)
+				db.InsertArticles(insertArticleSql, []Article{article})
+				for range ncomments[articleId-1] {
+					commentId++
+					comment := NewComment(
+						commentId,                 // id,
+						created(),                 // created,
+						articleId,                 // articleId,
+						commentTexts[commentId-1], // text,
+					)
+					db.InsertComments(insertCommentSql, []Comment{comment})
+				}
 			}
+			db.Commit()
 		}
-		db.Commit()
-	}
-	insertMillis := millisSince(t0)
+	}, func() {
+		db.Exec("DELETE FROM comments", "DELETE FROM articles", "DELETE FROM users")
+	})
+	stopProfiles()
 	if verbose {
-		log.Printf("  insert took %d ms", insertMillis)
+		log.Printf("  insert took %d ms", insert.millis())
 	}
 	// query user by email, with articles and comments
 	querySql := "SELECT" +
@@ -212,28 +384,33 @@ func benchReal(dbfile string, makeDb func(dbfile string) Db) {
 		" WHERE users.email = ?" +
 		" ORDER BY users.created, articles.created, comments.created"
 
-	t0 = time.Now()
-	users := make([]User, 0, nusers)
-	articles := make([]Article, 0, nusers*narticlesPerUser)
-	comments := make([]Comment, 0, nusers)
-	for _, email := range emails {
-		u, a, c := db.FindUsersArticlesComments(querySql, []any{email})
-		MustBeEqual(1, len(u))
-		MustBeEqual(narticlesPerUser, len(a))
-		MustBeEqual(narticlesPerUser*ncommentsPerArticle, len(c))
-		users = append(users, u...)
-		articles = append(articles, a...)
-		comments = append(comments, c...)
-	}
-	queryMillis := millisSince(t0)
+	stopProfiles = startProfiles("real.query", db.DriverName())
+	var users []User
+	var articles []Article
+	var comments []Comment
+	query := t.measure("real.query", func() {
+		users = make([]User, 0, nusers)
+		articles = make([]Article, 0, nusers*narticlesPerUser)
+		comments = make([]Comment, 0, nusers)
+		for iuser, email := range emails {
+			u, a, c := db.FindUsersArticlesComments(querySql, []any{email})
+			MustBeEqual(1, len(u))
+			MustBeEqual(narticles[iuser], len(a))
+			MustBeEqual(ncommentsPerUser[iuser], len(c))
+			users = append(users, u...)
+			articles = append(articles, a...)
+			comments = append(comments, c...)
+		}
+	}, nil)
+	stopProfiles()
 	if verbose {
-		log.Printf("  query took %d ms", queryMillis)
+		log.Printf("  query took %d ms", query.millis())
 	}
 	// validate query result
 	MustBeEqual(nusers, len(users))
 	MustBeEqual(nusers*narticlesPerUser, len(articles))
 	MustBeEqual(nusers*narticlesPerUser*ncommentsPerArticle, len(comments))
-	userId = 0
+	var userId int
 	lastCreated := base.Add(-1 * time.Second)
 	for iuser, user := range users {
 		userId++
@@ -243,7 +420,7 @@ func benchReal(dbfile string, makeDb func(dbfile string) Db) {
 		MustBeEqual(true, user.Active)
 		lastCreated = user.Created
 	}
-	articleId = 0
+	var articleId int
 	lastCreated = base.Add(-1 * time.Second)
 	var lastUserId int
 	for _, article := range articles {
@@ -251,11 +428,11 @@ func benchReal(dbfile string, makeDb func(dbfile string) Db) {
 		MustBeEqual(articleId, article.Id)
 		MustBe(article.Created.After(lastCreated))
 		MustBe(article.UserId == lastUserId || article.UserId == lastUserId+1)
-		MustBeEqual("text text text text text text text text text text text text", article.Text)
+		MustBeEqual(articleTexts[article.Id-1], article.Text)
 		lastCreated = article.Created
 		lastUserId = article.UserId
 	}
-	commentId = 0
+	var commentId int
 	lastCreated = base.Add(-1 * time.Second)
 	var lastArticleId int
 	for _, comment := range comments {
@@ -263,86 +440,48 @@ func benchReal(dbfile string, makeDb func(dbfile string) Db) {
 		MustBeEqual(commentId, comment.Id)
 		MustBe(comment.Created.After(lastCreated))
 		MustBe(comment.ArticleId == lastArticleId || comment.ArticleId == lastArticleId+1)
-		MustBeEqual("text text text text text text text text text text text text", comment.Text)
+		MustBeEqual(commentTexts[comment.Id-1], comment.Text)
 		lastCreated = comment.Created
 		lastArticleId = comment.ArticleId
 	}
 	// print results
 	bench := "2_real"
-	log.Printf("%s - insert - %-10s - %10d", bench, db.DriverName(), insertMillis)
-	log.Printf("%s - query  - %-10s - %10d", bench, db.DriverName(), queryMillis)
-	log.Printf("%s - dbsize - %-10s - %10d", bench, db.DriverName(), dbsize(dbfile))
+	logResult(bench, "insert", db.DriverName(), insert)
+	logResult(bench, "query", db.DriverName(), query)
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
 }
 
 // Insert 200 users in one database transaction.
 // Then insert 20000 articles (100 articles for each user) in another transaction.
 // Then insert 400000 articles (20 comments for each article) in another transaction.
 // Then query all users, articles and comments in one big JOIN statement.
-func benchComplex(dbfile string, makeDb func(dbfile string) Db) {
+func benchComplex(t *timing, dbfile string, makeDb func(dbfile string) Db) {
 	removeDbfiles(dbfile)
 	db := makeDb(dbfile)
 	defer db.Close()
 	initSchema(db)
-	const nusers = 200
-	const narticlesPerUser = 100
-	const ncommentsPerArticle = 20
+	nusers := scaled(200)
+	narticlesPerUser := scaled(100)
+	ncommentsPerArticle := scaled(20)
 	if verbose {
 		log.Printf("nusers = %d", nusers)
 		log.Printf("narticlesPerUser = %d", narticlesPerUser)
 		log.Printf("ncommentsPerArticle = %d", ncommentsPerArticle)
 	}
 	// make users, articles, comments
-	var users []User
-	var articles []Article
-	var comments []Comment
-	base := time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local)
-	var userId int
-	var articleId int
-	var commentId int
-	for range nusers {
-		userId++
-		user := NewUser(
-			userId, // id
-			base.Add(time.Duration(userId)*time.Minute), // created
-			fmt.Sprintf("user%08d@example.com", userId), // email
-			userId%2 == 0, // active
-		)
-		users = append(users, user)
-		for range narticlesPerUser {
-			articleId++
-			article := NewArticle(
-				articleId, // id
-				base.Add(time.Duration(articleId)*time.Minute), // created
-				userId,         // userId
-				"article text", // text
-			)
-			articles = append(articles, article)
-			for range ncommentsPerArticle {
-				commentId++
-				comment := NewComment(
-					commentId, // id
-					base.Add(time.Duration(commentId)*time.Minute), // created
-					articleId,      // articleId
-					"comment text", // text,
-				)
-				comments = append(comments, comment)
-			}
-		}
-	}
+	users, articles, comments := makeComplex(nusers, narticlesPerUser, ncommentsPerArticle)
+	wantUsers, wantArticles, wantComments := users, articles, comments
 	// insert users, articles, comments
-	t0 := time.Now()
-	db.Begin()
-	db.InsertUsers(insertUserSql, users)
-	db.Commit()
-	db.Begin()
-	db.InsertArticles(insertArticleSql, articles)
-	db.Commit()
-	db.Begin()
-	db.InsertComments(insertCommentSql, comments)
-	db.Commit()
-	insertMillis := millisSince(t0)
+	stopProfiles := startProfiles("complex.insert", db.DriverName())
+	insert := t.measure("complex.insert", func() {
+		insertComplex(db, users, articles, comments)
+	}, func() {
+		db.Exec("DELETE FROM comments", "DELETE FROM articles", "DELETE FROM users")
+	})
+	stopProfiles()
 	if verbose {
-		log.Printf("  insert took %d ms", insertMillis)
+		log.Printf("  insert took %d ms", insert.millis())
 	}
 	// query users, articles, comments in one big join
 	querySql := "SELECT" +
@@ -353,41 +492,30 @@ func benchComplex(dbfile string, makeDb func(dbfile string) Db) {
 		" LEFT JOIN articles ON articles.userId = users.id" +
 		" LEFT JOIN comments ON comments.articleId = articles.id" +
 		" ORDER BY users.created,  articles.created, comments.created"
-	t0 = time.Now()
-	users, articles, comments = db.FindUsersArticlesComments(querySql, nil)
-	queryMillis := millisSince(t0)
+	stopProfiles = startProfiles("complex.query", db.DriverName())
+	query := t.measure("complex.query", func() {
+		users, articles, comments = db.FindUsersArticlesComments(querySql, nil)
+	}, nil)
+	stopProfiles()
 	if verbose {
-		log.Printf("  query took %d ms", queryMillis)
+		log.Printf("  query took %d ms", query.millis())
 	}
 	// validate query result
 	MustBeEqual(nusers, len(users))
 	MustBeEqual(nusers*narticlesPerUser, len(articles))
 	MustBeEqual(nusers*narticlesPerUser*ncommentsPerArticle, len(comments))
 	for i, user := range users {
-		userId := i + 1
-		MustBeEqual(userId, user.Id)
-		MustBeEqual(base.Add(time.Duration(userId)*time.Minute), user.Created)
-		MustBeEqual(fmt.Sprintf("user%08d@example.com", userId), user.Email)
-		MustBeEqual(userId%2 == 0, user.Active)
+		MustBeEqual(wantUsers[i], user)
 	}
 	for i, article := range articles {
-		articleId := i + 1
-		MustBeEqual(articleId, article.Id)
-		MustBeEqual(base.Add(time.Duration(articleId)*time.Minute), article.Created)
-		MustBe(1 <= article.UserId && article.UserId <= 1+nusers)
-		MustBeEqual("article text", article.Text)
+		MustBeEqual(wantArticles[i], article)
 		if i > 0 {
 			last := articles[i-1]
 			MustBe(article.UserId >= last.UserId)
 		}
 	}
 	for i, comment := range comments {
-		commentId := i + 1
-		MustBeEqual(commentId, comment.Id)
-		MustBeEqual(base.Add(time.Duration(commentId)*time.Minute), comment.Created)
-		MustBe(comment.ArticleId >= 1)
-		MustBe(comment.ArticleId <= 1+(nusers*narticlesPerUser))
-		MustBeEqual("comment text", comment.Text)
+		MustBeEqual(wantComments[i], comment)
 		if i > 0 {
 			last := comments[i-1]
 			MustBe(comment.ArticleId >= last.ArticleId)
@@ -395,174 +523,263 @@ func benchComplex(dbfile string, makeDb func(dbfile string) Db) {
 	}
 	// print results
 	bench := "3_complex"
-	log.Printf("%s - insert - %-10s - %10d", bench, db.DriverName(), insertMillis)
-	log.Printf("%s - query  - %-10s - %10d", bench, db.DriverName(), queryMillis)
-	log.Printf("%s - dbsize - %-10s - %10d", bench, db.DriverName(), dbsize(dbfile))
+	logResult(bench, "insert", db.DriverName(), insert)
+	logResult(bench, "query", db.DriverName(), query)
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
+
+// makeComplex makes the users, articles and comments of benchComplex.
+func makeComplex(nusers, narticlesPerUser, ncommentsPerArticle int) ([]User, []Article, []Comment) {
+	g := newGenerator()
+	var users []User
+	var articles []Article
+	var comments []Comment
+	base := time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local)
+	userCreated := g.Clock(base.Add(time.Minute), time.Minute)
+	articleCreated := g.Clock(base.Add(time.Minute), time.Minute)
+	commentCreated := g.Clock(base.Add(time.Minute), time.Minute)
+	narticles := g.Counts(nusers, narticlesPerUser)                     // for each user
+	ncomments := g.Counts(nusers*narticlesPerUser, ncommentsPerArticle) // for each article
+	var userId int
+	var articleId int
+	var commentId int
+	for range nusers {
+		userId++
+		user := NewUser(
+			userId,                                  // id
+			userCreated(),                           // created
+			g.Email("user%08d@example.com", userId), // email
+			userId%2 == 0,                           // active
+		)
+		users = append(users, user)
+		for range narticles[userId-1] {
+			articleId++
+			article := NewArticle(
+				articleId,              // id
+				articleCreated(),       // created
+				userId,                 // userId
+				g.Text("article text"), // text
+			)
+			articles = append(articles, article)
+			for range ncomments[articleId-1] {
+				commentId++
+				comment := NewComment(
+					commentId,              // id
+					commentCreated(),       // created
+					articleId,              // articleId
+					g.Text("comment text"), // text,
+				)
+				comments = append(comments, comment)
+			}
+		}
+	}
+	return users, articles, comments
+}
+
+// insertComplex inserts users, articles and comments, each in one transaction.
+func insertComplex(db Db, users []User, articles []Article, comments []Comment) {
+	db.Begin()
+	db.InsertUsers(insertUserSql, users)
+	db.Commit()
+	db.Begin()
+	db.InsertArticles(insertArticleSql, articles)
+	db.Commit()
+	db.Begin()
+	db.InsertComments(insertCommentSql, comments)
+	db.Commit()
 }
 
 // Insert N users in one database transaction.
 // Then query all users 1000 times.
 // This benchmark is used to simulate a read-heavy use case.
-func benchMany(dbfile string, nusers int, makeDb func(dbfile string) Db) {
+func benchMany(t *timing, dbfile string, nusers int, makeDb func(dbfile string) Db) {
 	removeDbfiles(dbfile)
 	db := makeDb(dbfile)
 	defer db.Close()
 	initSchema(db)
 	// insert users
+	g := newGenerator()
 	var users []User
-	base := time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local)
+	created := g.Clock(time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local), time.Minute)
 	for iuser := range nusers {
 		users = append(users, NewUser(
-			iuser+1, // id,
-			base.Add(time.Duration(iuser)*time.Minute),   // created,
-			fmt.Sprintf("user%08d@example.com", iuser+1), // email,
-			true, // active,
+			iuser+1,                                  // id,
+			created(),                                // created,
+			g.Email("user%08d@example.com", iuser+1), // email,
+			true,                                     // active,
 		))
 	}
+	want := users
+	stopProfiles := startProfiles(fmt.Sprintf("many.%04d.insert", nusers), db.DriverName())
 	t0 := time.Now()
 	db.Begin()
 	db.InsertUsers(insertUserSql, users)
 	db.Commit()
 	insertMillis := millisSince(t0)
+	stopProfiles()
 	if verbose {
 		log.Printf("  insert took %d ms", insertMillis)
 	}
 	// query users 1000 times
-	t0 = time.Now()
-	for i := 0; i < 1000; i++ {
-		users = db.FindUsers("SELECT id,created,email,active FROM users ORDER BY id")
-		MustBeEqual(len(users), nusers)
-	}
-	queryMillis := millisSince(t0)
+	stopProfiles = startProfiles(fmt.Sprintf("many.%04d.query", nusers), db.DriverName())
+	query := t.measure(fmt.Sprintf("many.%04d.query", nusers), func() {
+		for range scaled(1000) {
+			users = db.FindUsers("SELECT id,created,email,active FROM users ORDER BY id")
+			MustBeEqual(len(users), nusers)
+		}
+	}, nil)
+	stopProfiles()
 	if verbose {
-		log.Printf("  query took %d ms", queryMillis)
+		log.Printf("  query took %d ms", query.millis())
 	}
 	// validate query result
 	for iuser, user := range users {
-		MustBeEqual(iuser+1, user.Id)
-		MustBeEqual(base.Add(time.Duration(iuser)*time.Minute), user.Created)
-		MustBeEqual(fmt.Sprintf("user%08d@example.com", iuser+1), user.Email)
-		MustBeEqual(true, user.Active)
+		MustBeEqual(want[iuser], user)
 	}
 	// print results
 	bench := fmt.Sprintf("4_many/%04d", nusers)
 	if verbose {
-		log.Printf("%s - insert - %-10s - %10d", bench, db.DriverName(), insertMillis)
+		logValue(bench, "insert", db.DriverName(), insertMillis)
 	}
-	log.Printf("%s - query  - %-10s - %10d", bench, db.DriverName(), queryMillis)
-	log.Printf("%s - dbsize - %-10s - %10d", bench, db.DriverName(), dbsize(dbfile))
+	logResult(bench, "query", db.DriverName(), query)
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
 }
 
 // Insert 10000 users with N bytes of row content.
 // Then query all users.
 // This benchmark is used to simulate reading of large (gigabytes) databases.
-func benchLarge(dbfile string, nsize int, makeDb func(dbfile string) Db) {
+func benchLarge(t *timing, dbfile string, nsize int, makeDb func(dbfile string) Db) {
 	removeDbfiles(dbfile)
 	db := makeDb(dbfile)
 	defer db.Close()
 	initSchema(db)
 	// insert user with large emails
+	g := newGenerator()
+	stopProfiles := startProfiles(fmt.Sprintf("large.%06d.insert", nsize), db.DriverName())
 	t0 := time.Now()
-	base := time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local)
-	const nusers = 10_000
+	created := g.Clock(time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local), time.Second)
+	nusers := scaled(10_000)
 	var users []User
 	for i := range nusers {
 		users = append(users, NewUser(
-			i+1,                                    // Id
-			base.Add(time.Duration(i)*time.Second), // Created
-			strings.Repeat("a", nsize),             // Email
-			true,                                   // Active
+			i+1,                                // Id
+			created(),                          // Created
+			g.Text(strings.Repeat("a", nsize)), // Email
+			true,                               // Active
 		))
 	}
+	want := users
 	db.Begin()
 	db.InsertUsers(insertUserSql, users)
 	db.Commit()
 	insertMillis := millisSince(t0)
+	stopProfiles()
 	// query users
-	t0 = time.Now()
-	users = db.FindUsers("SELECT id,created,email,active FROM users ORDER BY id")
-	MustBeEqual(len(users), nusers)
-	queryMillis := millisSince(t0)
+	stopProfiles = startProfiles(fmt.Sprintf("large.%06d.query", nsize), db.DriverName())
+	query := t.measure(fmt.Sprintf("large.%06d.query", nsize), func() {
+		users = db.FindUsers("SELECT id,created,email,active FROM users ORDER BY id")
+		MustBeEqual(len(users), nusers)
+	}, nil)
+	stopProfiles()
 	if verbose {
-		log.Printf("  query took %d ms", queryMillis)
+		log.Printf("  query took %d ms", query.millis())
 	}
 	// validate query result
 	for i, u := range users {
-		MustBeEqual(i+1, u.Id)
-		MustBeEqual(2023, u.Created.Year())
-		MustBeEqual("a", u.Email[0:1])
-		MustBeEqual(true, u.Active)
+		MustBeEqual(want[i], u)
 	}
 	// print results
 	bench := fmt.Sprintf("5_large/%06d", nsize)
 	if verbose {
-		log.Printf("%s - insert - %-10s - %10d", bench, db.DriverName(), insertMillis)
+		logValue(bench, "insert", db.DriverName(), insertMillis)
 	}
-	log.Printf("%s - query  - %-10s - %10d", bench, db.DriverName(), queryMillis)
-	log.Printf("%s - dbsize - %-10s - %10d", bench, db.DriverName(), dbsize(dbfile))
+	logResult(bench, "query", db.DriverName(), query)
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
 }
 
 // Insert one million users.
 // Then have N goroutines query all users.
 // This benchmark is used to simulate concurrent reads.
-func benchConcurrent(dbfile string, ngoroutines int, makeDb func(dbfile string) Db) {
+func benchConcurrent(t *timing, dbfile string, ngoroutines int, makeDb func(dbfile string) Db) {
 	removeDbfiles(dbfile)
 	db1 := makeDb(dbfile)
 	driverName := db1.DriverName()
+	bench := fmt.Sprintf("6_concurrent/%d", ngoroutines)
+	if exclusiveLocking() {
+		db1.Close()
+		logUnsupported(bench, "query", driverName) // readers cannot get a lock
+		return
+	}
 	initSchema(db1)
+	pragmas := effectivePragmas(db1)
 	// insert many users
-	base := time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local)
-	const nusers = 1_000_000
+	g := newGenerator()
+	created := g.Clock(time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local), time.Second)
+	nusers := scaled(1_000_000)
 	var users []User
 	for i := range nusers {
 		users = append(users, NewUser(
-			i+1,                                    // Id
-			base.Add(time.Duration(i)*time.Second), // Created
-			fmt.Sprintf("user%d@example.com", i+1), // Email
-			true,                                   // Active
+			i+1,                                // Id
+			created(),                          // Created
+			g.Email("user%d@example.com", i+1), // Email
+			true,                               // Active
 		))
 	}
+	stopProfiles := startProfiles(fmt.Sprintf("concurrent.%d.insert", ngoroutines), driverName)
 	t0 := time.Now()
 	db1.Begin()
 	db1.InsertUsers(insertUserSql, users)
 	db1.Commit()
-	db1.Close()
 	insertMillis := millisSince(t0)
-	// query users in N goroutines
-	t0 = time.Now()
-	var wg sync.WaitGroup
-	for range ngoroutines {
-		wg.Add(1)
-		db := makeDb(dbfile)
-		go func() {
-			defer wg.Done()
-			db.Exec(
-				"PRAGMA foreign_keys=1",
-				"PRAGMA busy_timeout=5000", // 5s busy timeout
-			)
-			defer db.Close()
-			users = db.FindUsers("SELECT id,created,email,active FROM users ORDER BY id")
-			MustBeEqual(len(users), nusers)
-			// validate query result
-			for i, u := range users {
-				MustBeEqual(i+1, u.Id)
-				MustBeEqual(2023, u.Created.Year())
-				MustBeEqual("user", u.Email[0:4])
-				MustBeEqual(true, u.Active)
-			}
-		}()
+	stopProfiles()
+	defer db1.Close() // keeps in-memory databases alive until all readers are done
+	// readers must see the users, which they do not if db1 is a private
+	// in-memory database, or lives in another process
+	probe := makeDb(dbfile)
+	visible := hasUsersTable(probe)
+	probe.Close()
+	if !visible {
+		logUnsupported(bench, "query", driverName)
+		return
 	}
-	// wait for completion
-	wg.Wait()
-	queryMillis := millisSince(t0)
+	// query users in N goroutines
+	stopProfiles = startProfiles(fmt.Sprintf("concurrent.%d.query", ngoroutines), driverName)
+	query := t.measure(fmt.Sprintf("concurrent.%d.query", ngoroutines), func() {
+		var wg sync.WaitGroup
+		for range ngoroutines {
+			wg.Add(1)
+			db := makeDb(dbfile)
+			go func() {
+				defer wg.Done()
+				db.Exec(tunedPragmas...)
+				db.Exec(
+					"PRAGMA foreign_keys=1",
+					"PRAGMA busy_timeout=5000", // 5s busy timeout
+				)
+				defer db.Close()
+				found := db.FindUsers("SELECT id,created,email,active FROM users ORDER BY id")
+				MustBeEqual(len(found), nusers)
+				// validate query result
+				for i, u := range found {
+					MustBeEqual(users[i], u)
+				}
+			}()
+		}
+		// wait for completion
+		wg.Wait()
+	}, nil)
+	stopProfiles()
 	if verbose {
-		log.Printf("  query took %d ms", queryMillis)
+		log.Printf("  query took %d ms", query.millis())
 	}
 	// print results
-	bench := fmt.Sprintf("6_concurrent/%d", ngoroutines)
 	if verbose {
-		log.Printf("%s - insert - %-10s - %10d", bench, driverName, insertMillis)
+		logValue(bench, "insert", driverName, insertMillis)
 	}
-	log.Printf("%s - query  - %-10s - %10d", bench, driverName, queryMillis)
-	log.Printf("%s - dbsize - %-10s - %10d", bench, driverName, dbsize(dbfile))
+	logResult(bench, "query", driverName, query)
+	logValue(bench, "dbsize", driverName, dbsize(dbfile))
+	logText(bench, "pragma", driverName, pragmas)
 }
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+// attachDbfile returns the dbfile of an attached database: a file next to
+// dbfile with the schema name appended to its base name, e.g.
+// "bench_articledb.db" for "bench.db", or a private in-memory database if
+// dbfile is an in-memory database.
+func attachDbfile(dbfile, schema string) string {
+	if isMemoryDbfile(dbfile) {
+		return memoryDbfile
+	}
+	ext := filepath.Ext(dbfile)
+	return strings.TrimSuffix(dbfile, ext) + "_" + schema + ext
+}
+
+// Split the users, articles and comments of the complex benchmark into
+// three database files: users in the main database, articles and comments
+// in two attached databases, all in one connection.
+// Insert them, then query them in the LEFT JOIN of the complex benchmark,
+// across the schemas.
+// Then run one transaction per user that updates one row in the main
+// database only (local), and one that updates one row in each of the three
+// databases (commit), which SQLite commits atomically with a super-journal.
+// This benchmark is used to simulate services that shard their data into
+// several database files.
+func benchAttach(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+	schemas := []string{"articledb", "commentdb"}
+	dbfiles := []string{dbfile}
+	for _, schema := range schemas {
+		dbfiles = append(dbfiles, attachDbfile(dbfile, schema))
+	}
+	removeDbfiles(dbfiles...)
+	// ATTACH applies to one connection only
+	db := makeOneConnDb(dbfile, makeDb)
+	defer db.Close()
+	initSchema(db)
+	db.Exec("DROP TABLE comments", "DROP TABLE articles")
+	for i, schema := range schemas {
+		db.ExecParams("ATTACH DATABASE ? AS "+schema, [][]any{{dbfiles[i+1]}})
+		// journal mode, synchronous and the tuned pragmas are per database
+		db.Exec(
+			"PRAGMA "+schema+".journal_mode=DELETE",
+			"PRAGMA "+schema+".synchronous=FULL",
+		)
+		for _, pragma := range tunedPragmas {
+			db.Exec(strings.Replace(pragma, "PRAGMA ", "PRAGMA "+schema+".", 1))
+		}
+	}
+	// foreign keys cannot reference tables of other databases
+	db.Exec(
+		"CREATE TABLE articledb.articles ("+
+			"id INTEGER PRIMARY KEY NOT NULL,"+
+			" created INTEGER NOT NULL, "+ // time.Time
+			" userId INTEGER NOT NULL,"+
+			" text TEXT NOT NULL)",
+		"CREATE INDEX articledb.articles_created ON articles(created)",
+		"CREATE INDEX articledb.articles_userId ON articles(userId)",
+		"CREATE TABLE commentdb.comments ("+
+			"id INTEGER PRIMARY KEY NOT NULL,"+
+			" created INTEGER NOT NULL, "+ // time.Time
+			" articleId INTEGER NOT NULL,"+
+			" text TEXT NOT NULL)",
+		"CREATE INDEX commentdb.comments_created ON comments(created)",
+		"CREATE INDEX commentdb.comments_articleId ON comments(articleId)",
+	)
+	users, articles, comments := makeComplex(scaled(200), scaled(100), scaled(20))
+	// insert users, articles, comments, the unqualified table names of
+	// insertComplex resolve to the attached databases
+	stopProfiles := startProfiles("attach.insert", db.DriverName())
+	insert := t.measure("attach.insert", func() {
+		insertComplex(db, users, articles, comments)
+	}, func() {
+		db.Exec("DELETE FROM commentdb.comments", "DELETE FROM articledb.articles", "DELETE FROM main.users")
+	})
+	stopProfiles()
+	// query users, articles, comments in one big join across the databases
+	querySql := "SELECT" +
+		" users.id, users.created, users.email, users.active," +
+		" articles.id, articles.created, articles.userId, articles.text," +
+		" comments.id, comments.created, comments.articleId, comments.text" +
+		" FROM main.users AS users" +
+		" LEFT JOIN articledb.articles AS articles ON articles.userId = users.id" +
+		" LEFT JOIN commentdb.comments AS comments ON comments.articleId = articles.id" +
+		" ORDER BY users.created,  articles.created, comments.created"
+	var foundUsers []User
+	var foundArticles []Article
+	var foundComments []Comment
+	stopProfiles = startProfiles("attach.query", db.DriverName())
+	query := t.measure("attach.query", func() {
+		foundUsers, foundArticles, foundComments = db.FindUsersArticlesComments(querySql, nil)
+	}, nil)
+	stopProfiles()
+	// validate query result
+	MustBeEqual(len(users), len(foundUsers))
+	MustBeEqual(len(articles), len(foundArticles))
+	MustBeEqual(len(comments), len(foundComments))
+	for i, user := range foundUsers {
+		MustBeEqual(users[i], user)
+	}
+	for i, article := range foundArticles {
+		MustBeEqual(articles[i], article)
+	}
+	for i, comment := range foundComments {
+		MustBeEqual(comments[i], comment)
+	}
+	// one transaction per user, in the main database only, and across all
+	// three databases
+	ntx := len(users)
+	transact := func(name string, sqls []string) measurement {
+		stopProfiles := startProfiles("attach."+name, db.DriverName())
+		m := t.measure("attach."+name, func() {
+			for i := range ntx {
+				db.Begin()
+				for _, s := range sqls {
+					db.ExecParams(s, [][]any{{fmt.Sprintf("%s %d", name, i+1), i + 1}})
+				}
+				db.Commit()
+			}
+		}, nil)
+		stopProfiles()
+		return m
+	}
+	local := transact("local", []string{
+		"UPDATE main.users SET email=? WHERE id=?",
+	})
+	commit := transact("commit", []string{
+		"UPDATE main.users SET email=? WHERE id=?",
+		"UPDATE articledb.articles SET text=? WHERE id=?",
+		"UPDATE commentdb.comments SET text=? WHERE id=?",
+	})
+	for _, table := range []string{"main.users WHERE email", "articledb.articles WHERE text", "commentdb.comments WHERE text"} {
+		MustBeEqual(int64(ntx), queryInt64(db, "SELECT count(*) FROM "+table+" LIKE 'commit %'"))
+	}
+	if verbose {
+		log.Printf("  %d transactions, dbfiles %s", ntx, strings.Join(dbfiles, ","))
+	}
+	// print results
+	bench := "20_attach"
+	logResult(bench, "insert", db.DriverName(), insert)
+	logResult(bench, "query", db.DriverName(), query)
+	logResult(bench, "local", db.DriverName(), local)
+	logResult(bench, "commit", db.DriverName(), commit)
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfiles...))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
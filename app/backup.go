@@ -0,0 +1,94 @@
+package app
+
+import (
+	"log"
+	"strings"
+)
+
+// backupStep is the number of pages per step of the backup API, set with
+// the -backupstep flag. If it is negative, all pages are copied in one step.
+var backupStep = 100
+
+// Insert the users, articles and comments of the complex benchmark.
+// Then copy the database into a new file with the online backup API of
+// the driver, backupStep pages per step, and with VACUUM INTO.
+// Verify each copy with PRAGMA integrity_check and row counts.
+// This benchmark is used to simulate online backups.
+// The results are MB (of database pages) per second, not millis.
+func benchBackup(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	db := makeDb(dbfile)
+	defer db.Close()
+	bench := "14_backup"
+	if isMemoryDbfile(dbfile) {
+		logUnsupported(bench, "api", db.DriverName()) // the copies are files next to dbfile
+		logUnsupported(bench, "vacuum", db.DriverName())
+		return
+	}
+	initSchema(db)
+	users, articles, comments := makeComplex(scaled(200), scaled(100), scaled(20))
+	insertComplex(db, users, articles, comments)
+	size := queryInt64(db, "PRAGMA page_count") * queryInt64(db, "PRAGMA page_size")
+	backupfile := dbfile + "-backup"
+	defer removeDbfiles(backupfile)
+	// verifyCopy checks the copy in backupfile
+	verifyCopy := func() {
+		cdb := makeDb(backupfile)
+		defer cdb.Close()
+		values := cdb.QueryStrings("PRAGMA integrity_check", nil)
+		MustBeEqual(1, len(values))
+		MustBeEqual("ok", values[0])
+		MustBeEqual(int64(len(users)), queryInt64(cdb, "SELECT count(*) FROM users"))
+		MustBeEqual(int64(len(articles)), queryInt64(cdb, "SELECT count(*) FROM articles"))
+		MustBeEqual(int64(len(comments)), queryInt64(cdb, "SELECT count(*) FROM comments"))
+	}
+	// copy with the backup API
+	var api measurement
+	bdb, apiOk := db.(BackupDb)
+	if apiOk {
+		removeDbfiles(backupfile)
+		stopProfiles := startProfiles("backup.api", db.DriverName())
+		api = t.measure("backup.api", func() {
+			apiOk = bdb.Backup(backupfile, backupStep)
+		}, func() {
+			removeDbfiles(backupfile)
+		})
+		stopProfiles()
+		if apiOk {
+			verifyCopy()
+		}
+	}
+	// copy with VACUUM INTO, available since SQLite 3.27.0
+	var vacuum measurement
+	vacuumOk := sqliteVersion(db) >= 3_027_000
+	if vacuumOk {
+		removeDbfiles(backupfile)
+		stopProfiles := startProfiles("backup.vacuum", db.DriverName())
+		vacuum = t.measure("backup.vacuum", func() {
+			db.Exec("VACUUM INTO '" + strings.ReplaceAll(backupfile, "'", "''") + "'")
+		}, func() {
+			removeDbfiles(backupfile)
+		})
+		stopProfiles()
+		verifyCopy()
+	}
+	if verbose {
+		log.Printf("  backup step %d pages, api took %d ms, vacuum took %d ms", backupStep, api.millis(), vacuum.millis())
+	}
+	// print results
+	mbPerSec := func(m measurement) int64 {
+		return int64(float64(size) / 1e6 / m.perOp().Seconds())
+	}
+	if apiOk {
+		logValue(bench, "api", db.DriverName(), mbPerSec(api))
+	} else {
+		logUnsupported(bench, "api", db.DriverName())
+	}
+	if vacuumOk {
+		logValue(bench, "vacuum", db.DriverName(), mbPerSec(vacuum))
+	} else {
+		logUnsupported(bench, "vacuum", db.DriverName())
+	}
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
@@ -0,0 +1,183 @@
+package app
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// blobChunk is the number of bytes per incremental blob read and write,
+// set with the -blobchunk flag.
+var blobChunk = 64 << 10
+
+// checkBlobChunk exits if blobChunk is not positive.
+func checkBlobChunk() {
+	if blobChunk <= 0 {
+		log.Fatalf("invalid blobchunk %d, want > 0", blobChunk)
+	}
+}
+
+// readRSS reads the resident set size of this process and its peak, in
+// bytes, ok is false if they are not available (e.g. on non-Linux
+// systems), see proc(5), /proc/pid/status.
+func readRSS() (rss, peak int64, ok bool) {
+	data, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return 0, 0, false
+	}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "VmRSS":
+			rss = n << 10
+		case "VmHWM":
+			peak = n << 10
+		}
+	}
+	return rss, peak, rss > 0 && peak > 0
+}
+
+// resetPeakRSS returns unused memory to the OS and resets the peak
+// resident set size of this process to its resident set size, see proc(5),
+// /proc/pid/clear_refs. It reports false if that is not available.
+func resetPeakRSS() bool {
+	debug.FreeOSMemory()
+	return os.WriteFile("/proc/self/clear_refs", []byte("5"), 0) == nil
+}
+
+// blobChecker is a Writer that checks that it is written want.
+type blobChecker struct {
+	want []byte
+	off  int
+}
+
+func (c *blobChecker) Write(p []byte) (int, error) {
+	MustBe(c.off+len(p) <= len(c.want))
+	MustBe(bytes.Equal(c.want[c.off:c.off+len(p)], p))
+	c.off += len(p)
+	return len(p), nil
+}
+
+// Insert 50 blobs of 4 MB each in one database transaction, by binding
+// each blob as a whole. Then read each blob as a whole.
+// Then do the same with incremental blob I/O, blobChunk bytes at a time:
+// insert zeroblobs, write them in chunks, and read them in chunks.
+// This benchmark is used to simulate storing files in the database.
+// The results are MB per second, not millis. On Linux, the growth of the
+// peak resident set size of the process is reported in MB, read from
+// /proc/self/status.
+func benchBlob(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	db := makeDb(dbfile)
+	defer db.Close()
+	initSchema(db)
+	db.Exec("CREATE TABLE blobs (id INTEGER PRIMARY KEY NOT NULL, data BLOB NOT NULL)")
+	nblobs := scaled(50)
+	size := scaled(4 << 20)
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7 / 3)
+	}
+	wantData := string(data)
+	// measurePeak measures op like measure does, and the growth of the
+	// peak resident set size while it ran
+	measurePeak := func(name string, op func(), reset func()) (measurement, int64) {
+		resetPeakRSS()
+		before, _, _ := readRSS()
+		stopProfiles := startProfiles(name, db.DriverName())
+		m := t.measure(name, op, reset)
+		stopProfiles()
+		_, peak, _ := readRSS()
+		return m, peak - before
+	}
+	// write and read whole blobs
+	params := make([][]any, 0, nblobs)
+	for i := range nblobs {
+		params = append(params, []any{i + 1, data})
+	}
+	write, writePeak := measurePeak("blob.whole.write", func() {
+		db.Begin()
+		db.ExecParams("INSERT INTO blobs(id,data) VALUES(?,?)", params)
+		db.Commit()
+	}, func() {
+		db.Exec("DELETE FROM blobs")
+	})
+	read, readPeak := measurePeak("blob.whole.read", func() {
+		for i := range nblobs {
+			values := db.QueryStrings("SELECT data FROM blobs WHERE id=?", []any{i + 1})
+			MustBeEqual(1, len(values))
+			MustBe(values[0] == wantData)
+		}
+	}, nil)
+	db.Exec("DELETE FROM blobs")
+	// write and read blobs in chunks
+	bdb, chunkOk := db.(BlobDb)
+	var chunkWrite, chunkRead measurement
+	var chunkWritePeak, chunkReadPeak int64
+	if chunkOk {
+		zeroParams := make([][]any, 0, nblobs)
+		for i := range nblobs {
+			zeroParams = append(zeroParams, []any{i + 1, size})
+		}
+		chunkWrite, chunkWritePeak = measurePeak("blob.chunks.write", func() {
+			db.Begin()
+			db.ExecParams("INSERT INTO blobs(id,data) VALUES(?,zeroblob(?))", zeroParams)
+			for i := 0; i < nblobs && chunkOk; i++ {
+				chunkOk = bdb.WriteBlob("blobs", "data", int64(i+1), bytes.NewReader(data), blobChunk)
+			}
+			db.Commit()
+		}, func() {
+			db.Exec("DELETE FROM blobs")
+		})
+	}
+	if chunkOk {
+		chunkRead, chunkReadPeak = measurePeak("blob.chunks.read", func() {
+			for i := range nblobs {
+				checker := &blobChecker{want: data}
+				MustBe(bdb.ReadBlob("blobs", "data", int64(i+1), checker, blobChunk))
+				MustBeEqual(size, checker.off)
+			}
+		}, nil)
+	}
+	_, _, rssOk := readRSS()
+	rssOk = rssOk && resetPeakRSS()
+	if verbose {
+		log.Printf("  %d blobs of %d bytes, chunks of %d bytes", nblobs, size, blobChunk)
+	}
+	// print results
+	mbPerSec := func(m measurement) int64 {
+		return int64(float64(nblobs*size) / 1e6 / m.perOp().Seconds())
+	}
+	logResults := func(bench string, ok bool, write, read measurement, writePeak, readPeak int64) {
+		if !ok {
+			for _, op := range []string{"write", "read", "wpeak", "rpeak"} {
+				logUnsupported(bench, op, db.DriverName())
+			}
+			return
+		}
+		logValue(bench, "write", db.DriverName(), mbPerSec(write))
+		logValue(bench, "read", db.DriverName(), mbPerSec(read))
+		if rssOk {
+			logValue(bench, "wpeak", db.DriverName(), writePeak/1e6)
+			logValue(bench, "rpeak", db.DriverName(), readPeak/1e6)
+		} else {
+			logUnsupported(bench, "wpeak", db.DriverName())
+			logUnsupported(bench, "rpeak", db.DriverName())
+		}
+	}
+	bench := "19_blob"
+	logResults(bench+"/whole", true, write, read, writePeak, readPeak)
+	logResults(bench+"/chunks", chunkOk, chunkWrite, chunkRead, chunkWritePeak, chunkReadPeak)
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
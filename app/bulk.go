@@ -0,0 +1,106 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Insert the 1 million users of the simple benchmark in one database
+// transaction, with different batching strategies:
+// one row per statement execution, like all other benchmarks (rows),
+// multi-row VALUES with N rows per statement (values/N),
+// one JSON array of N users per statement, spread into rows by
+// json_each (json/N), and one row per statement execution into a
+// staging table without indexes, then INSERT ... SELECT into users (stage).
+// sqinn executes rows, and the statements of all other strategies, in one
+// batched Exec call per statement.
+// The batches are made before the timed inserts.
+// This benchmark is used to simulate bulk loads.
+func benchBulk(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	db := makeDb(dbfile)
+	defer db.Close()
+	initSchema(db)
+	nusers := scaled(1_000_000)
+	users := makeSimpleUsers(nusers)
+	// validate checks the inserted users
+	validate := func() {
+		values := db.FindUsers("SELECT id,created,email,active FROM users ORDER BY id")
+		MustBeEqual(len(users), len(values))
+		for i, u := range values {
+			MustBeEqual(users[i], u)
+		}
+	}
+	// insert runs one strategy and prints its result
+	insert := func(strategy string, op func()) {
+		bench := "16_bulk/" + strategy
+		stopProfiles := startProfiles("bulk."+strings.ReplaceAll(strategy, "/", ".")+".insert", db.DriverName())
+		m := t.measure("bulk."+strings.ReplaceAll(strategy, "/", ".")+".insert", func() {
+			db.Begin()
+			op()
+			db.Commit()
+		}, func() {
+			db.Exec("DELETE FROM users")
+		})
+		stopProfiles()
+		validate()
+		db.Exec("DELETE FROM users")
+		logResult(bench, "insert", db.DriverName(), m)
+	}
+	// one row per statement
+	insert("rows", func() {
+		db.InsertUsers(insertUserSql, users)
+	})
+	// multi-row VALUES, at most 999 parameters before SQLite 3.32.0
+	for _, batchSize := range []int{10, 100, 200} {
+		insertSql := "INSERT INTO users(id,created,email,active) VALUES " +
+			strings.Repeat("(?,?,?,?),", batchSize-1) + "(?,?,?,?)"
+		var params [][]any
+		for i := 0; i+batchSize <= nusers; i += batchSize {
+			batch := make([]any, 0, 4*batchSize)
+			for _, u := range users[i : i+batchSize] {
+				batch = append(batch, u.Id, BindTime(u.Created), u.Email, u.Active)
+			}
+			params = append(params, batch)
+		}
+		rest := users[len(params)*batchSize:]
+		insert(fmt.Sprintf("values/%04d", batchSize), func() {
+			db.ExecParams(insertSql, params)
+			if len(rest) > 0 {
+				db.InsertUsers(insertUserSql, rest)
+			}
+		})
+	}
+	// JSON arrays
+	const jsonSql = "INSERT INTO users(id,created,email,active)" +
+		" SELECT json_extract(value,'$[0]'),json_extract(value,'$[1]'),json_extract(value,'$[2]'),json_extract(value,'$[3]')" +
+		" FROM json_each(?)"
+	for _, batchSize := range []int{1_000, 10_000} {
+		var params [][]any
+		for i := 0; i < nusers; i += batchSize {
+			var batch [][]any
+			for _, u := range users[i:min(i+batchSize, nusers)] {
+				batch = append(batch, []any{u.Id, BindTime(u.Created), u.Email, u.Active})
+			}
+			data, err := json.Marshal(batch)
+			MustBeNil(err)
+			params = append(params, []any{string(data)})
+		}
+		insert(fmt.Sprintf("json/%05d", batchSize), func() {
+			db.ExecParams(jsonSql, params)
+		})
+	}
+	// staging table, temporary tables belong to the connection of the transaction
+	insert("stage", func() {
+		db.Exec("CREATE TEMP TABLE users_stage(id INTEGER, created INTEGER, email TEXT, active INTEGER)")
+		db.InsertUsers("INSERT INTO users_stage(id,created,email,active) VALUES(?,?,?,?)", users)
+		db.Exec(
+			"INSERT INTO users(id,created,email,active) SELECT id,created,email,active FROM users_stage ORDER BY id",
+			"DROP TABLE users_stage",
+		)
+	})
+	bench := "16_bulk"
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Insert 10000 users in one database transaction.
+// Then start an expensive query (a cross join of all users), cancel it
+// after 100 ms and measure the time until the query returns. Repeat 10 times.
+// In verify mode, the expensive query is a count of a recursive CTE.
+// After each abort, verify that the connection is still usable.
+// This benchmark is used to simulate cancelled HTTP requests.
+// The abort result is the average time-to-abort in microseconds, not millis.
+func benchCancel(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	db := makeDb(dbfile)
+	defer db.Close()
+	initSchema(db)
+	// insert users
+	g := newGenerator()
+	var users []User
+	created := g.Clock(time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local), time.Minute)
+	nusers := 10_000
+	delay := 100 * time.Millisecond
+	querySql := "SELECT count(*) FROM users a, users b WHERE a.email < b.email"
+	if verifyMode {
+		// a cross join of fewer users may complete before the delay on fast
+		// machines, 10 million rows of a recursive CTE take far longer, and
+		// still complete if a driver cannot interrupt a running statement
+		nusers, delay = 1_000, 10*time.Millisecond
+		querySql = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c WHERE x < 10000000) SELECT count(*) FROM c"
+	}
+	for i := range nusers {
+		users = append(users, NewUser(
+			i+1,                                  // id,
+			created(),                            // created,
+			g.Email("user%08d@example.com", i+1), // email,
+			true,                                 // active,
+		))
+	}
+	want := users
+	db.Begin()
+	db.InsertUsers(insertUserSql, users)
+	db.Commit()
+	// start and cancel expensive queries, with -duration until the time budget is used
+	nrounds := scaled(10)
+	var total time.Duration
+	stopProfiles := startProfiles("cancel.abort", db.DriverName())
+	start := time.Now()
+	more := func(rounds int) bool {
+		return rounds < nrounds || time.Since(start) < benchDuration
+	}
+	loop := t.loopOf("cancel.abort")
+	if loop != nil {
+		more = func(int) bool { return loop.Loop() }
+	}
+	rounds := 0
+	for ; more(rounds); rounds++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		canceled := make(chan time.Time, 1)
+		time.AfterFunc(delay, func() {
+			canceled <- time.Now()
+			cancel()
+		})
+		values, err := db.QueryStringsContext(ctx, querySql, nil)
+		t1 := time.Now()
+		t0 := <-canceled // query must not complete before it was canceled
+		MustBeSet(err)
+		MustBe(values == nil)
+		total += t1.Sub(t0)
+		// connection must still be usable
+		users, err = db.FindUsersContext(context.Background(), "SELECT id,created,email,active FROM users ORDER BY id LIMIT 10")
+		MustBeNil(err)
+		MustBeEqual(10, len(users))
+		for i, u := range users {
+			MustBeEqual(want[i], u)
+		}
+	}
+	stopProfiles()
+	abortMicros := total.Microseconds() / int64(rounds)
+	if loop != nil {
+		// the loop times whole rounds, with the delay before the abort
+		loop.ReportMetric(float64(total.Nanoseconds())/float64(rounds), "abort-ns/op")
+	}
+	if verbose {
+		log.Printf("  abort took %d us", abortMicros)
+	}
+	// print results
+	bench := "10_cancel"
+	if benchDuration == 0 {
+		logValue(bench, "abort", db.DriverName(), abortMicros)
+	} else {
+		logResult(bench, "abort", db.DriverName(), measurement{n: rounds, elapsed: total})
+	}
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/cvilsmeier/go-sqlite-bench/app/gen"
+)
+
+// dataset is the dataset the benchmarks insert, gen.Regular or gen.Realistic.
+var dataset = gen.Regular
+
+// dataSeed seeds the realistic dataset.
+var dataSeed uint64 = 1
+
+// checkDataset exits if dataset is unknown.
+func checkDataset() {
+	datasets := []string{gen.Regular, gen.Realistic}
+	if !slices.Contains(datasets, dataset) {
+		log.Fatalf("unknown dataset %q, want one of %s", dataset, strings.Join(datasets, ","))
+	}
+}
+
+// newGenerator returns a new data generator. Each benchmark makes its own,
+// so that it inserts the same data, no matter which benchmarks ran before.
+func newGenerator() *gen.Generator {
+	return gen.New(dataset, dataSeed)
+}
@@ -1,8 +1,16 @@
 package app
 
-import "time"
+import (
+	"context"
+	"io"
+	"iter"
+	"time"
+)
 
-// Db is the database interface.
+// Db is the database interface. Exec, ExecParams and QueryStrings run in
+// the transaction of Begin, if one is active. The errors of TryExecParams
+// have a Code() int method that returns the extended result code, if the
+// driver has one, see CodeError.
 type Db interface {
 	DriverName() string
 	Exec(sqls ...string)
@@ -12,10 +20,74 @@ type Db interface {
 	InsertArticles(insertSql string, articles []Article)
 	InsertComments(insertSql string, comments []Comment)
 	FindUsers(querySql string) []User
+	StreamUsers(querySql string) iter.Seq[*User] // yields the same *User, overwritten for each row
 	FindUsersArticlesComments(querySql string, params []any) ([]User, []Article, []Comment)
+	ExecParams(execSql string, params [][]any)           // executes execSql once for each params row
+	TryExecParams(execSql string, params [][]any) error  // like ExecParams, but returns errors, e.g. constraint violations
+	QueryStrings(querySql string, params []any) []string // returns the first column of each row as text
+	FindUsersContext(ctx context.Context, querySql string) ([]User, error)
+	QueryStringsContext(ctx context.Context, querySql string, params []any) ([]string, error)
 	Close()
 }
 
+// CodeError adds the extended result code of SQLite, e.g. 787 for
+// SQLITE_CONSTRAINT_FOREIGNKEY, to the error of a driver that has no
+// Code() int method.
+type CodeError struct {
+	Err          error
+	ExtendedCode int
+}
+
+func (e *CodeError) Error() string { return e.Err.Error() }
+func (e *CodeError) Unwrap() error { return e.Err }
+func (e *CodeError) Code() int     { return e.ExtendedCode }
+
+// PoolDb is a Db that is backed by a pool of connections and can be
+// shared by concurrent goroutines for queries. Transactions are not
+// shared, Begin and Commit must not be called concurrently.
+type PoolDb interface {
+	Db
+	PoolSize() int // the max number of connections, 0 is unlimited
+}
+
+// FuncDb is a Db that can register SQL functions and collations
+// implemented in Go. Each method reports false if the driver does not
+// support it. Registration must happen before the first statement is
+// executed, since some drivers apply it only to connections opened later.
+type FuncDb interface {
+	Db
+	CreateScalarFunc(name string, fn func(x int64) int64) bool
+	CreateAggregateFunc(name string, newAggregate func() Aggregate) bool
+	CreateCollation(name string, cmp func(a, b string) int) bool
+}
+
+// BackupDb is a Db that can copy its main database into a new file with
+// the SQLite online backup API, pagesPerStep pages per backup step, or all
+// pages in one step if pagesPerStep is negative. Backup reports false if
+// the driver does not support it.
+type BackupDb interface {
+	Db
+	Backup(dstfile string, pagesPerStep int) bool
+}
+
+// BlobDb is a Db that can write and read blobs incrementally, with the
+// SQLite incremental blob I/O API, chunkSize bytes per call. WriteBlob
+// overwrites the blob in column of the row of table, which must have the
+// size of src already, e.g. from zeroblob(n). Both run in the transaction
+// of Begin, if one is active. Each method reports false if the driver does
+// not support it.
+type BlobDb interface {
+	Db
+	WriteBlob(table, column string, rowid int64, src io.Reader, chunkSize int) bool
+	ReadBlob(table, column string, rowid int64, dst io.Writer, chunkSize int) bool
+}
+
+// Aggregate is one invocation of an aggregate SQL function implemented in Go.
+type Aggregate interface {
+	Step(x int64)
+	Final() int64
+}
+
 // User is a registered User who can access the blog.
 type User struct {
 	Id      int
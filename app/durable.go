@@ -0,0 +1,206 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// processIO holds I/O counters of processes, see proc(5), /proc/pid/io.
+type processIO struct {
+	syscw      int64 // number of write syscalls
+	wchar      int64 // bytes passed to write syscalls
+	writeBytes int64 // bytes sent to the storage layer
+}
+
+// readProcessIO reads the I/O counters of this process and its descendants,
+// e.g. the sqinn process, ok is false if they are not available (e.g. on
+// non-Linux systems).
+func readProcessIO() (pio processIO, ok bool) {
+	ok = true
+	for _, pid := range append([]int{os.Getpid()}, descendants(os.Getpid())...) {
+		f, err := os.Open(fmt.Sprintf("/proc/%d/io", pid))
+		if err != nil {
+			return pio, false
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			key, value, found := strings.Cut(scanner.Text(), ":")
+			if !found {
+				continue
+			}
+			n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "syscw":
+				pio.syscw += n
+			case "wchar":
+				pio.wchar += n
+			case "write_bytes":
+				pio.writeBytes += n
+			}
+		}
+		ok = ok && scanner.Err() == nil
+		f.Close()
+	}
+	return pio, ok
+}
+
+// descendants returns the pids of the child processes of pid, and of their
+// child processes, and so on. It returns no pids if /proc is not available.
+func descendants(pid int) []int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	children := make(map[int][]int)
+	for _, e := range entries {
+		child, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile("/proc/" + e.Name() + "/stat")
+		if err != nil {
+			continue
+		}
+		// format: pid (comm) state ppid ..., comm may contain spaces
+		_, rest, found := strings.Cut(string(data), ") ")
+		fields := strings.Fields(rest)
+		if !found || len(fields) < 2 {
+			continue
+		}
+		ppid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		children[ppid] = append(children[ppid], child)
+	}
+	var pids []int
+	for todo := children[pid]; len(todo) > 0; todo = todo[1:] {
+		pids = append(pids, todo[0])
+		todo = append(todo, children[todo[0]]...)
+	}
+	return pids
+}
+
+// synchronousNames maps the values of PRAGMA synchronous to their names.
+var synchronousNames = map[string]string{"0": "off", "1": "normal", "2": "full", "3": "extra"}
+
+// Insert 1000 users, each in its own autocommit statement.
+// Then insert 1000 users, each in its own explicit transaction.
+// Do this for each combination of journal_mode and synchronous.
+// This benchmark is used to measure the cost of durable commits.
+// The commit results are commits per second, not millis.
+// On Linux, the write syscalls, the bytes written and the bytes sent to
+// the storage layer are reported, read from /proc/pid/io of this process
+// and its descendants. The fsync and fdatasync calls are not counted, the
+// syncs are reported as unsupported, see the README for strace.
+// With -duration, the I/O counters are per 1000 commits.
+func benchDurable(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+	for _, journalMode := range []string{"DELETE", "TRUNCATE", "WAL"} {
+		for _, synchronous := range []string{"OFF", "NORMAL", "FULL"} {
+			benchDurableMode(t, dbfile, journalMode, synchronous, makeDb)
+		}
+	}
+}
+
+func benchDurableMode(t *timing, dbfile string, journalMode, synchronous string, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	// synchronous applies to one connection only
+	db := makeOneConnDb(dbfile, makeDb)
+	defer db.Close()
+	initSchema(db)
+	db.Exec(
+		"PRAGMA journal_mode="+journalMode,
+		"PRAGMA synchronous="+synchronous,
+	)
+	// read back the effective modes, in-memory databases cannot use WAL, for instance
+	values := db.QueryStrings("PRAGMA journal_mode", nil)
+	MustBeEqual(1, len(values))
+	effectiveJournalMode := strings.ToLower(values[0])
+	values = db.QueryStrings("PRAGMA synchronous", nil)
+	MustBeEqual(1, len(values))
+	effectiveSynchronous := synchronousNames[values[0]]
+	// make users
+	g := newGenerator()
+	created := g.Clock(time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local), time.Minute)
+	ncommits := scaled(1000)
+	var users []User
+	for i := range 2 * ncommits {
+		users = append(users, NewUser(
+			i+1,                                  // id,
+			created(),                            // created,
+			g.Email("user%08d@example.com", i+1), // email,
+			true,                                 // active,
+		))
+	}
+	params := make([][]any, 0, ncommits)
+	for _, u := range users[:ncommits] {
+		params = append(params, []any{u.Id, BindTime(u.Created), u.Email, u.Active})
+	}
+	// measureIO measures op and sums up the I/O of its runs, not of the resets
+	_, ioOk := readProcessIO()
+	measureIO := func(section string, op func(), reset func()) (measurement, processIO) {
+		var sum processIO
+		m := t.measure(section, func() {
+			before, _ := readProcessIO()
+			op()
+			after, _ := readProcessIO()
+			sum.syscw += after.syscw - before.syscw
+			sum.wchar += after.wchar - before.wchar
+			sum.writeBytes += after.writeBytes - before.writeBytes
+		}, reset)
+		return m, sum
+	}
+	// insert users in autocommit mode
+	name := fmt.Sprintf("durable.%s.%s.auto", effectiveJournalMode, effectiveSynchronous)
+	stopProfiles := startProfiles(name, db.DriverName())
+	auto, autoIO := measureIO(name, func() {
+		db.ExecParams(insertUserSql, params) // no transaction, every statement commits
+	}, func() {
+		db.Exec(fmt.Sprintf("DELETE FROM users WHERE id <= %d", ncommits))
+	})
+	stopProfiles()
+	// insert users in explicit transactions
+	name = fmt.Sprintf("durable.%s.%s.tx", effectiveJournalMode, effectiveSynchronous)
+	stopProfiles = startProfiles(name, db.DriverName())
+	tx, txIO := measureIO(name, func() {
+		for i := ncommits; i < 2*ncommits; i++ {
+			db.Begin()
+			db.InsertUsers(insertUserSql, users[i:i+1])
+			db.Commit()
+		}
+	}, func() {
+		db.Exec(fmt.Sprintf("DELETE FROM users WHERE id > %d", ncommits))
+	})
+	stopProfiles()
+	// validate
+	values = db.QueryStrings("SELECT count(*) FROM users", nil)
+	MustBeEqual(1, len(values))
+	MustBeEqual(strconv.Itoa(2*ncommits), values[0])
+	// print results
+	logResults := func(bench string, m measurement, pio processIO) {
+		logValue(bench, "commit", db.DriverName(), int64(float64(m.n*ncommits)/m.elapsed.Seconds()))
+		logUnsupported(bench, "syncs", db.DriverName())
+		if ioOk {
+			n := int64(m.n)
+			logValue(bench, "syscw", db.DriverName(), pio.syscw/n)
+			logValue(bench, "wchar", db.DriverName(), pio.wchar/n)
+			logValue(bench, "wbytes", db.DriverName(), pio.writeBytes/n)
+		} else {
+			logUnsupported(bench, "syscw", db.DriverName())
+			logUnsupported(bench, "wchar", db.DriverName())
+			logUnsupported(bench, "wbytes", db.DriverName())
+		}
+	}
+	bench := fmt.Sprintf("11_durable/%s/%s", effectiveJournalMode, effectiveSynchronous)
+	logResults(bench+"/auto", auto, autoIO)
+	logResults(bench+"/tx", tx, txIO)
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
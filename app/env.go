@@ -0,0 +1,127 @@
+package app
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// logEnvironment prints the environment the benchmarks run in, so that
+// every result set documents the machine, the build and the SQLite library
+// it was produced with.
+func logEnvironment(dbfile string, makeDb func(dbfile string) Db) {
+	logEnv := func(key, value string) {
+		log.Printf("env - %-14s - %s", key, value)
+	}
+	logEnv("os/arch", runtime.GOOS+"/"+runtime.GOARCH)
+	logEnv("go", runtime.Version())
+	logEnv("cpu", cpuModel())
+	logEnv("cores", strconv.Itoa(runtime.NumCPU()))
+	logEnv("gomaxprocs", strconv.Itoa(runtime.GOMAXPROCS(0)))
+	logEnv("kernel", kernelVersion())
+	if isMemoryDbfile(dbfile) {
+		logEnv("filesystem", "memory")
+	} else {
+		logEnv("filesystem", filesystemType(filepath.Dir(dbfile)))
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			logEnv("module", dep.Path+" "+dep.Version)
+		}
+	}
+	// ask the SQLite library
+	removeDbfiles(dbfile)
+	db := makeDb(dbfile)
+	values := db.QueryStrings("SELECT sqlite_version()", nil)
+	MustBeEqual(1, len(values))
+	logEnv("sqlite", values[0])
+	for _, option := range db.QueryStrings("PRAGMA compile_options", nil) {
+		logEnv("sqlite_option", option)
+	}
+	db.Close()
+	removeDbfiles(dbfile)
+}
+
+// cpuModel returns the CPU model name from /proc/cpuinfo,
+// or "unknown" if that is not available.
+func cpuModel() string {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return "unknown"
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok && strings.TrimSpace(key) == "model name" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return "unknown"
+}
+
+// kernelVersion returns the kernel release from /proc,
+// or "unknown" if that is not available.
+func kernelVersion() string {
+	data, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// filesystemType returns the type of the filesystem that holds dir, by
+// looking up the longest matching mount point in /proc/self/mountinfo,
+// or "unknown" if that is not available.
+func filesystemType(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "unknown"
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return "unknown"
+	}
+	defer f.Close()
+	fstype := "unknown"
+	var mountPoint string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// format: id parent major:minor root mountpoint options [optional...] - fstype source superoptions
+		left, right, ok := strings.Cut(scanner.Text(), " - ")
+		if !ok {
+			continue
+		}
+		leftFields := strings.Fields(left)
+		rightFields := strings.Fields(right)
+		if len(leftFields) < 5 || len(rightFields) < 1 {
+			continue
+		}
+		mp := strings.ReplaceAll(leftFields[4], `\040`, " ")
+		if !isPathPrefix(mp, dir) || len(mp) < len(mountPoint) {
+			continue
+		}
+		mountPoint = mp
+		fstype = rightFields[0]
+	}
+	return fstype
+}
+
+// isPathPrefix reports whether dir is prefix itself or lies below prefix.
+func isPathPrefix(prefix, dir string) bool {
+	if prefix == "/" || prefix == dir {
+		return true
+	}
+	return strings.HasPrefix(dir, prefix+"/")
+}
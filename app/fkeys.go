@@ -0,0 +1,135 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Insert the users, articles and comments of the complex benchmark, once
+// with foreign_keys off and once on, into tables with ON DELETE CASCADE.
+// Then delete 10% of the users, which deletes their articles and comments.
+// Then insert articles and comments of users and articles that do not
+// exist, and verify that the driver reports the violations as errors.
+// This benchmark is used to measure the cost of foreign keys.
+func benchFkeys(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	// foreign_keys applies to one connection only
+	db := makeOneConnDb(dbfile, makeDb)
+	defer db.Close()
+	initSchema(db)
+	// the same tables, with cascading deletes
+	db.Exec(
+		"DROP TABLE comments",
+		"DROP TABLE articles",
+		"CREATE TABLE articles ("+
+			"id INTEGER PRIMARY KEY NOT NULL,"+
+			" created INTEGER NOT NULL, "+ // time.Time
+			" userId INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,"+
+			" text TEXT NOT NULL)",
+		"CREATE INDEX articles_created ON articles(created)",
+		"CREATE INDEX articles_userId ON articles(userId)",
+		"CREATE TABLE comments ("+
+			"id INTEGER PRIMARY KEY NOT NULL,"+
+			" created INTEGER NOT NULL, "+ // time.Time
+			" articleId INTEGER NOT NULL REFERENCES articles(id) ON DELETE CASCADE,"+
+			" text TEXT NOT NULL)",
+		"CREATE INDEX comments_created ON comments(created)",
+		"CREATE INDEX comments_articleId ON comments(articleId)",
+	)
+	users, articles, comments := makeComplex(scaled(200), scaled(100), scaled(20))
+	// count checks the number of rows of each table
+	count := func(nusers, narticles, ncomments int) {
+		MustBeEqual(int64(nusers), queryInt64(db, "SELECT count(*) FROM users"))
+		MustBeEqual(int64(narticles), queryInt64(db, "SELECT count(*) FROM articles"))
+		MustBeEqual(int64(ncomments), queryInt64(db, "SELECT count(*) FROM comments"))
+	}
+	// insert with foreign keys off and on
+	inserts := make(map[string]measurement)
+	for _, mode := range []string{"off", "on"} {
+		db.Exec("PRAGMA foreign_keys=" + mode)
+		values := db.QueryStrings("PRAGMA foreign_keys", nil)
+		MustBeEqual(1, len(values))
+		MustBeEqual(map[string]string{"off": "0", "on": "1"}[mode], values[0])
+		stopProfiles := startProfiles("fkeys."+mode+".insert", db.DriverName())
+		inserts[mode] = t.measure("fkeys."+mode+".insert", func() {
+			insertComplex(db, users, articles, comments)
+		}, func() {
+			db.Exec("DELETE FROM comments", "DELETE FROM articles", "DELETE FROM users")
+		})
+		stopProfiles()
+		count(len(users), len(articles), len(comments))
+		if mode == "off" {
+			db.Exec("DELETE FROM comments", "DELETE FROM articles", "DELETE FROM users")
+		}
+	}
+	// delete users, cascading to their articles and comments
+	ndelete := max(1, len(users)/10)
+	var deletedArticles []Article
+	var deletedComments []Comment
+	deleted := make(map[int]bool)
+	for _, a := range articles {
+		if a.UserId <= ndelete {
+			deletedArticles = append(deletedArticles, a)
+			deleted[a.Id] = true
+		}
+	}
+	for _, c := range comments {
+		if deleted[c.ArticleId] {
+			deletedComments = append(deletedComments, c)
+		}
+	}
+	stopProfiles := startProfiles("fkeys.on.delete", db.DriverName())
+	del := t.measure("fkeys.on.delete", func() {
+		db.Begin()
+		db.Exec(fmt.Sprintf("DELETE FROM users WHERE id <= %d", ndelete))
+		db.Commit()
+	}, func() {
+		insertComplex(db, users[:ndelete], deletedArticles, deletedComments)
+	})
+	stopProfiles()
+	count(len(users)-ndelete, len(articles)-len(deletedArticles), len(comments)-len(deletedComments))
+	MustBeEqual(0, len(db.QueryStrings("PRAGMA foreign_key_check", nil)))
+	// violate foreign keys, in autocommit mode
+	violations := []struct {
+		insertSql string
+		params    []any
+	}{
+		{insertArticleSql, []any{len(articles) + 1, 0, ndelete, "article of a deleted user"}},
+		{insertArticleSql, []any{len(articles) + 1, 0, len(users) + 1, "article of no user"}},
+		{insertCommentSql, []any{len(comments) + 1, 0, len(articles) + 1, "comment of no article"}},
+	}
+	for _, v := range violations {
+		err := db.TryExecParams(v.insertSql, [][]any{v.params})
+		Must(err != nil, "%s must fail", v.params[3])
+		Must(isForeignKeyError(err), "want a foreign key error, got %v", err)
+	}
+	count(len(users)-ndelete, len(articles)-len(deletedArticles), len(comments)-len(deletedComments))
+	if verbose {
+		log.Printf("  deleted %d users, %d articles, %d comments", ndelete, len(deletedArticles), len(deletedComments))
+	}
+	// print results
+	bench := "18_fkeys"
+	logResult(bench+"/off", "insert", db.DriverName(), inserts["off"])
+	logResult(bench+"/on", "insert", db.DriverName(), inserts["on"])
+	logResult(bench+"/on", "delete", db.DriverName(), del)
+	logValue(bench+"/on", "errors", db.DriverName(), len(violations))
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
+
+// sqliteConstraintForeignKey is the extended result code of foreign key
+// violations.
+const sqliteConstraintForeignKey = 787
+
+// isForeignKeyError reports whether err is a foreign key violation, by its
+// extended result code, see CodeError, or, if the driver has none, by the
+// message of SQLite.
+func isForeignKeyError(err error) bool {
+	var coded interface{ Code() int }
+	if errors.As(err, &coded) {
+		return coded.Code() == sqliteConstraintForeignKey
+	}
+	return strings.Contains(err.Error(), "FOREIGN KEY constraint failed")
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// goScalar is a scalar SQL function implemented in Go.
+func goScalar(x int64) int64 {
+	return x*2 + 1
+}
+
+// goSum is an aggregate SQL function implemented in Go.
+type goSum struct {
+	sum int64
+}
+
+func newGoSum() Aggregate {
+	return &goSum{}
+}
+
+func (a *goSum) Step(x int64) {
+	a.sum += x
+}
+
+func (a *goSum) Final() int64 {
+	return a.sum
+}
+
+// goDesc is a collation implemented in Go, it sorts in descending order.
+func goDesc(a, b string) int {
+	return strings.Compare(b, a)
+}
+
+// Insert 1 million users in one database transaction.
+// Then call a scalar and an aggregate SQL function implemented in Go for every user.
+// Then create an index that uses a collation implemented in Go.
+// This benchmark is used to measure calls from SQLite back into Go.
+func benchFuncs(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	db := makeDb(dbfile)
+	defer db.Close()
+	// register functions, before any statement is executed
+	fdb, ok := db.(FuncDb)
+	scalarOk := ok && fdb.CreateScalarFunc("go_scalar", goScalar)
+	aggregateOk := ok && fdb.CreateAggregateFunc("go_sum", newGoSum)
+	collationOk := ok && fdb.CreateCollation("go_desc", goDesc)
+	initSchema(db)
+	// insert users
+	g := newGenerator()
+	var users []User
+	created := g.Clock(time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local), time.Minute)
+	nusers := scaled(1_000_000)
+	for i := range nusers {
+		users = append(users, NewUser(
+			i+1,                                  // id,
+			created(),                            // created,
+			g.Email("user%08d@example.com", i+1), // email,
+			true,                                 // active,
+		))
+	}
+	stopProfiles := startProfiles("funcs.insert", db.DriverName())
+	t0 := time.Now()
+	db.Begin()
+	db.InsertUsers(insertUserSql, users)
+	db.Commit()
+	insertMillis := millisSince(t0)
+	stopProfiles()
+	// call a built-in expression, as a baseline
+	stopProfiles = startProfiles("funcs.native", db.DriverName())
+	native := t.measure("funcs.native", func() {
+		MustBeEqual(int64(nusers*(nusers+1)+nusers), queryInt64(db, "SELECT sum(id*2+1) FROM users"))
+	}, nil)
+	stopProfiles()
+	// call scalar function
+	var scalar measurement
+	if scalarOk {
+		stopProfiles = startProfiles("funcs.scalar", db.DriverName())
+		scalar = t.measure("funcs.scalar", func() {
+			MustBeEqual(int64(nusers*(nusers+1)+nusers), queryInt64(db, "SELECT sum(go_scalar(id)) FROM users"))
+		}, nil)
+		stopProfiles()
+	}
+	// call aggregate function
+	var aggregate measurement
+	if aggregateOk {
+		stopProfiles = startProfiles("funcs.aggr", db.DriverName())
+		aggregate = t.measure("funcs.aggr", func() {
+			MustBeEqual(int64(nusers*(nusers+1)/2), queryInt64(db, "SELECT go_sum(id) FROM users"))
+		}, nil)
+		stopProfiles()
+		// each invocation and each group has an aggregate of its own
+		MustBeEqual(int64(nusers*(nusers+1)/2), queryInt64(db, "SELECT go_sum(id) - go_sum(0) FROM users"))
+		values := db.QueryStrings("SELECT go_sum(id) FROM users GROUP BY id % 2 ORDER BY id % 2", nil)
+		MustBeEqual(2, len(values))
+		MustBeEqual(strconv.Itoa((nusers/2)*(nusers/2+1)), values[0])
+	}
+	// create index with collation
+	var collation measurement
+	if collationOk {
+		stopProfiles = startProfiles("funcs.collat", db.DriverName())
+		collation = t.measure("funcs.collat", func() {
+			db.Exec("CREATE INDEX users_email_desc ON users(email COLLATE go_desc)")
+		}, func() {
+			db.Exec("DROP INDEX users_email_desc")
+		})
+		stopProfiles()
+		emails := db.QueryStrings("SELECT email FROM users ORDER BY email COLLATE go_desc LIMIT 3", nil)
+		MustBeEqual(3, len(emails))
+		wantEmails := make([]string, 0, nusers)
+		for _, u := range users {
+			wantEmails = append(wantEmails, u.Email)
+		}
+		slices.SortFunc(wantEmails, goDesc)
+		for i, email := range emails {
+			MustBeEqual(wantEmails[i], email)
+		}
+	}
+	// print results
+	bench := "8_funcs"
+	if verbose {
+		logValue(bench, "insert", db.DriverName(), insertMillis)
+	}
+	logResult(bench, "native", db.DriverName(), native)
+	if scalarOk {
+		logResult(bench, "scalar", db.DriverName(), scalar)
+	} else {
+		logUnsupported(bench, "scalar", db.DriverName())
+	}
+	if aggregateOk {
+		logResult(bench, "aggr", db.DriverName(), aggregate)
+	} else {
+		logUnsupported(bench, "aggr", db.DriverName())
+	}
+	if collationOk {
+		logResult(bench, "collat", db.DriverName(), collation)
+	} else {
+		logUnsupported(bench, "collat", db.DriverName())
+	}
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
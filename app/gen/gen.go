@@ -0,0 +1,221 @@
+// Package gen generates the data the benchmarks insert.
+//
+// A Generator makes either regular data, which is the perfectly regular data
+// the benchmarks always used (sequential emails, identical texts, timestamps
+// spaced exactly one step apart), or realistic data: unique emails made of
+// (partly non-ASCII) names, variable-length text of Zipf-distributed words,
+// Zipf-distributed child counts and randomly spaced timestamps.
+// Realistic data depends on the seed only, the same seed and the same
+// sequence of calls always make the same data.
+package gen
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Datasets, selected with the -dataset flag.
+const (
+	Regular   = "regular"
+	Realistic = "realistic"
+)
+
+// Generator generates benchmark data.
+type Generator struct {
+	realistic bool
+	rnd       *rand.Rand
+	corpus    string // realistic text, made on first use
+	starts    []int  // offsets of the words in corpus
+}
+
+// New returns a Generator for a dataset, Regular or Realistic.
+func New(dataset string, seed uint64) *Generator {
+	if dataset != Regular && dataset != Realistic {
+		panic(fmt.Sprintf("unknown dataset %q", dataset))
+	}
+	return &Generator{
+		realistic: dataset == Realistic,
+		rnd:       rand.New(rand.NewPCG(seed, seed^0x5eed)),
+	}
+}
+
+// Realistic reports whether g makes realistic data.
+func (g *Generator) Realistic() bool {
+	return g.realistic
+}
+
+// Email returns fmt.Sprintf(format, id) for regular data, or a realistic
+// email address that is unique for id.
+func (g *Generator) Email(format string, id int) string {
+	if !g.realistic {
+		return fmt.Sprintf(format, id)
+	}
+	first := firstNames[g.rnd.IntN(len(firstNames))]
+	last := lastNames[g.rnd.IntN(len(lastNames))]
+	domain := domains[g.rnd.IntN(len(domains))]
+	switch g.rnd.IntN(4) {
+	case 0:
+		return fmt.Sprintf("%s.%s.%d@%s", first, last, id, domain)
+	case 1:
+		initial, _ := utf8.DecodeRuneInString(first)
+		return fmt.Sprintf("%c%s%d@%s", initial, last, id, domain)
+	case 2:
+		return fmt.Sprintf("%s_%d@%s", first, id, domain)
+	default:
+		return fmt.Sprintf("%s%d.%s@%s", last, id, first, domain)
+	}
+}
+
+// Text returns regular for regular data, or a realistic text whose length
+// varies around the length of regular (log-normal, at least one word).
+// Realistic texts are cut from a corpus of Zipf-distributed words and share
+// its memory, so large texts are cheap.
+func (g *Generator) Text(regular string) string {
+	if !g.realistic {
+		return regular
+	}
+	if g.corpus == "" {
+		g.makeCorpus()
+	}
+	size := int(float64(len(regular)) * math.Exp(g.rnd.NormFloat64()*0.5-0.125))
+	size = min(max(size, 1), len(g.corpus)/2)
+	i := g.rnd.IntN(sort.SearchInts(g.starts, len(g.corpus)-size+1)) // a word that leaves room for size bytes
+	start := g.starts[i]
+	// end at the word boundary before start+size, or after the first word
+	j := sort.SearchInts(g.starts, start+size+1) - 1
+	if j <= i {
+		j = i + 1
+	}
+	end := len(g.corpus)
+	if j < len(g.starts) {
+		end = g.starts[j] - 1 // without the space
+	}
+	return g.corpus[start:end]
+}
+
+// corpusSize is the size of the realistic text corpus in bytes.
+const corpusSize = 1 << 20
+
+func (g *Generator) makeCorpus() {
+	zipf := rand.NewZipf(g.rnd, 1.1, 1, uint64(len(words)-1))
+	var sb strings.Builder
+	sb.Grow(corpusSize + 64)
+	for sb.Len() < corpusSize {
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		g.starts = append(g.starts, sb.Len())
+		sb.WriteString(words[zipf.Uint64()])
+	}
+	g.corpus = sb.String()
+}
+
+// Clock returns a function that returns a new timestamp on each call,
+// starting at base. For regular data the timestamps are exactly step apart,
+// for realistic data they are randomly (exponentially) spaced with a mean
+// of step. Timestamps are strictly increasing and have millisecond
+// precision, so they survive BindTime.
+func (g *Generator) Clock(base time.Time, step time.Duration) func() time.Time {
+	next := base
+	return func() time.Time {
+		t := next
+		if g.realistic {
+			gap := time.Duration(g.rnd.ExpFloat64() * float64(step)).Truncate(time.Millisecond)
+			next = next.Add(max(gap, time.Millisecond))
+		} else {
+			next = next.Add(step)
+		}
+		return t
+	}
+}
+
+// Counts returns the number of children for each of n parents, e.g. the
+// number of articles for each user. For regular data, each parent has mean
+// children. For realistic data, the counts are Zipf-distributed (a few
+// parents have many children, most have few) in random order, each parent
+// has at least one child and the total is n*mean, like for regular data.
+func (g *Generator) Counts(n, mean int) []int {
+	counts := make([]int, n)
+	if !g.realistic || mean <= 1 {
+		for i := range counts {
+			counts[i] = mean
+		}
+		return counts
+	}
+	// one child each, distribute the rest by 1/rank weights
+	rest := n * (mean - 1)
+	weights := make([]float64, n)
+	var sum float64
+	for i := range weights {
+		weights[i] = 1 / float64(i+1)
+		sum += weights[i]
+	}
+	g.rnd.Shuffle(n, func(i, j int) { weights[i], weights[j] = weights[j], weights[i] })
+	type remainder struct {
+		i int
+		r float64
+	}
+	remainders := make([]remainder, n)
+	total := 0
+	for i, w := range weights {
+		share := float64(rest) * w / sum
+		counts[i] = 1 + int(share)
+		total += int(share)
+		remainders[i] = remainder{i, share - math.Floor(share)}
+	}
+	// largest remainders get the children lost by rounding down
+	slices.SortStableFunc(remainders, func(a, b remainder) int {
+		switch {
+		case a.r > b.r:
+			return -1
+		case a.r < b.r:
+			return 1
+		}
+		return 0
+	})
+	for k := range rest - total {
+		counts[remainders[k].i]++
+	}
+	return counts
+}
+
+// words are ranked by frequency, most frequent first, with some
+// non-ASCII words mixed in.
+var words = []string{
+	"the", "of", "and", "to", "a", "in", "is", "it", "you", "that",
+	"he", "was", "for", "on", "are", "with", "as", "I", "his", "they",
+	"be", "at", "one", "have", "this", "from", "or", "had", "by", "not",
+	"word", "but", "what", "some", "we", "can", "out", "other", "were", "all",
+	"there", "when", "up", "use", "your", "how", "said", "an", "each", "she",
+	"which", "do", "their", "time", "if", "will", "way", "about", "many", "then",
+	"them", "write", "would", "like", "so", "these", "her", "long", "make", "thing",
+	"see", "him", "two", "has", "look", "more", "day", "could", "go", "come",
+	"did", "number", "sound", "no", "most", "people", "my", "over", "know", "water",
+	"than", "call", "first", "who", "may", "down", "side", "been", "now", "find",
+	"café", "naïve", "über", "déjà", "vu", "façade", "résumé", "jalapeño", "smörgåsbord", "fiancée",
+	"database", "query", "index", "page", "cache", "driver", "benchmark", "latency", "throughput", "transaction",
+	"Straße", "Grüße", "größer", "Øresund", "Łódź", "København", "São", "Paulo", "Zürich", "Reykjavík",
+	"日本語", "東京", "データ", "中文", "数据库", "한국어", "서울", "Москва", "данные", "привет",
+	"Αθήνα", "δεδομένα", "עברית", "العربية", "हिन्दी", "ไทย", "🙂", "👍", "🚀", "☕",
+}
+
+var firstNames = []string{
+	"anna", "ben", "chloé", "david", "elif", "françois", "greta", "hiro", "inès", "jan",
+	"józef", "kai", "léa", "łukasz", "maría", "noah", "oğuz", "paul", "zoë", "søren",
+	"tomás", "ulla", "vera", "wei", "xavier", "yusuf", "zara", "émile", "ólafur", "иван",
+}
+
+var lastNames = []string{
+	"müller", "smith", "garcía", "nguyen", "kowalski", "rossi", "dubois", "jensen", "yılmaz", "novák",
+	"tanaka", "kim", "silva", "o'brien", "van-dijk", "schröder", "núñez", "andersson", "łęcki", "petrov",
+}
+
+var domains = []string{
+	"example.com", "example.org", "example.net", "mail.example", "exämple.de",
+}
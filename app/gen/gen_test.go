@@ -0,0 +1,136 @@
+package gen
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCounts(t *testing.T) {
+	tests := []struct {
+		dataset string
+		n, mean int
+	}{
+		{Regular, 1, 1},
+		{Regular, 1, 10},
+		{Regular, 1000, 1},
+		{Regular, 1000, 10},
+		{Realistic, 1, 1},
+		{Realistic, 1, 10},
+		{Realistic, 2, 2},
+		{Realistic, 1000, 1},
+		{Realistic, 1000, 10},
+		{Realistic, 10000, 3},
+	}
+	for _, tt := range tests {
+		counts := New(tt.dataset, 1).Counts(tt.n, tt.mean)
+		if len(counts) != tt.n {
+			t.Errorf("%s Counts(%d, %d): got %d counts", tt.dataset, tt.n, tt.mean, len(counts))
+			continue
+		}
+		sum := 0
+		for i, c := range counts {
+			if c < 1 {
+				t.Errorf("%s Counts(%d, %d): counts[%d] = %d, want >= 1", tt.dataset, tt.n, tt.mean, i, c)
+			}
+			if tt.dataset == Regular && c != tt.mean {
+				t.Errorf("%s Counts(%d, %d): counts[%d] = %d, want %d", tt.dataset, tt.n, tt.mean, i, c, tt.mean)
+			}
+			sum += c
+		}
+		if sum != tt.n*tt.mean {
+			t.Errorf("%s Counts(%d, %d): sum %d, want %d", tt.dataset, tt.n, tt.mean, sum, tt.n*tt.mean)
+		}
+		if tt.dataset == Realistic {
+			checkZipf(t, tt.n, tt.mean, counts)
+		}
+	}
+}
+
+// checkZipf checks that the count of rank r, minus the one child that
+// every parent has, is the share 1/r of the rest, rounded up or down.
+func checkZipf(t *testing.T, n, mean int, counts []int) {
+	t.Helper()
+	sorted := slices.Clone(counts)
+	slices.SortFunc(sorted, func(a, b int) int { return b - a })
+	var h float64
+	for r := 1; r <= n; r++ {
+		h += 1 / float64(r)
+	}
+	rest := float64(n * (mean - 1))
+	for i, c := range sorted {
+		share := rest / (h * float64(i+1))
+		if float64(c-1) < share-1 || float64(c-1) > share+1 {
+			t.Errorf("Realistic Counts(%d, %d): rank %d has %d children, want 1+%.1f", n, mean, i+1, c, share)
+			return
+		}
+	}
+	if n < 1000 || mean < 2 {
+		return // too few children to be skewed
+	}
+	if sorted[0] <= 10*sorted[n/2] {
+		t.Errorf("Realistic Counts(%d, %d): max %d, median %d, want a skewed distribution", n, mean, sorted[0], sorted[n/2])
+	}
+	if slices.Equal(counts, sorted) {
+		t.Errorf("Realistic Counts(%d, %d): counts are sorted, want random order", n, mean)
+	}
+}
+
+func TestClock(t *testing.T) {
+	base := time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local)
+	tests := []struct {
+		dataset string
+		step    time.Duration
+	}{
+		{Regular, time.Millisecond},
+		{Regular, time.Minute},
+		{Realistic, time.Millisecond},
+		{Realistic, time.Second},
+		{Realistic, time.Minute},
+	}
+	for _, tt := range tests {
+		const n = 10_000
+		clock := New(tt.dataset, 1).Clock(base, tt.step)
+		prev := clock()
+		if !prev.Equal(base) {
+			t.Errorf("%s Clock(%s): first %s, want base %s", tt.dataset, tt.step, prev, base)
+		}
+		for i := 1; i < n; i++ {
+			next := clock()
+			if !next.After(prev) {
+				t.Errorf("%s Clock(%s): %s after %s, want strictly increasing", tt.dataset, tt.step, next, prev)
+				break
+			}
+			if !next.Equal(next.Truncate(time.Millisecond)) {
+				t.Errorf("%s Clock(%s): %s, want millisecond precision", tt.dataset, tt.step, next)
+				break
+			}
+			if tt.dataset == Regular && next.Sub(prev) != tt.step {
+				t.Errorf("%s Clock(%s): gap %s, want %s", tt.dataset, tt.step, next.Sub(prev), tt.step)
+				break
+			}
+			prev = next
+		}
+		// realistic gaps are random, but their mean is about step
+		mean := prev.Sub(base) / (n - 1)
+		if mean < tt.step*9/10 || mean > tt.step*11/10+time.Millisecond {
+			t.Errorf("%s Clock(%s): mean gap %s, want about %s", tt.dataset, tt.step, mean, tt.step)
+		}
+	}
+}
+
+func TestSeed(t *testing.T) {
+	a := New(Realistic, 42)
+	b := New(Realistic, 42)
+	if !slices.Equal(a.Counts(100, 5), b.Counts(100, 5)) {
+		t.Errorf("same seed, different counts")
+	}
+	ca := a.Clock(time.Unix(0, 0), time.Second)
+	cb := b.Clock(time.Unix(0, 0), time.Second)
+	for range 100 {
+		if !ca().Equal(cb()) {
+			t.Errorf("same seed, different timestamps")
+			break
+		}
+	}
+}
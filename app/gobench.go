@@ -0,0 +1,176 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// goBenchmark is a Go benchmark, e.g. BenchmarkManyQuery, that times one
+// section of a benchmark, with one sub-benchmark per case, see package
+// gobench.
+type goBenchmark struct {
+	name  string // without the "Benchmark" prefix, e.g. "ManyQuery"
+	cases []goCase
+}
+
+// goCase is a sub-benchmark of a goBenchmark.
+type goCase struct {
+	name    string // e.g. "0010", or "" for the only case
+	section string // e.g. "many.0010.query", see startProfiles
+	run     func(t *timing, dbfile string, makeDb func(dbfile string) Db)
+}
+
+// oneCase returns the only case of a benchmark without parameters.
+func oneCase(section string, run func(t *timing, dbfile string, makeDb func(dbfile string) Db)) []goCase {
+	return []goCase{{"", section, run}}
+}
+
+// intCases returns one case per parameter, named with format, e.g.
+// "%04d", the section is sectionFormat with the name, e.g. "many.%s.query".
+func intCases(params []int, format string, sectionFormat string, run func(t *timing, dbfile string, param int, makeDb func(dbfile string) Db)) []goCase {
+	var cases []goCase
+	for _, param := range params {
+		name := fmt.Sprintf(format, param)
+		cases = append(cases, goCase{name, fmt.Sprintf(sectionFormat, name), func(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+			run(t, dbfile, param, makeDb)
+		}})
+	}
+	return cases
+}
+
+// nameCases returns one case per name, the section is sectionFormat with
+// the name, e.g. "bulk.%s.insert". Each case runs the whole benchmark.
+func nameCases(names []string, sectionFormat string, run func(t *timing, dbfile string, makeDb func(dbfile string) Db)) []goCase {
+	var cases []goCase
+	for _, name := range names {
+		cases = append(cases, goCase{name, fmt.Sprintf(sectionFormat, name), run})
+	}
+	return cases
+}
+
+// goBenchmarks returns the Go benchmarks, one per timed section of the
+// benchmarks, except procs, which runs the bench binary as its writers.
+func goBenchmarks() []goBenchmark {
+	var gbs []goBenchmark
+	add := func(name string, cases []goCase) {
+		gbs = append(gbs, goBenchmark{name, cases})
+	}
+	add("SimpleInsert", oneCase("simple.insert", benchSimple))
+	add("SimpleQuery", oneCase("simple.query", benchSimple))
+	add("RealInsert", oneCase("real.insert", benchReal))
+	add("RealQuery", oneCase("real.query", benchReal))
+	add("ComplexInsert", oneCase("complex.insert", benchComplex))
+	add("ComplexQuery", oneCase("complex.query", benchComplex))
+	add("ManyQuery", intCases([]int{10, 100, 1_000}, "%04d", "many.%s.query", benchMany))
+	add("LargeQuery", intCases([]int{50_000, 100_000, 200_000}, "%06d", "large.%s.query", benchLarge))
+	add("ConcurrentQuery", intCases([]int{2, 4, 8}, "%d", "concurrent.%s.query", benchConcurrent))
+	for _, op := range []string{"insert", "extract", "arrow", "each", "group", "index", "idxqry"} {
+		add("Json"+title(op), intCases(jsonDepths, "%02d", "json.%s."+op, benchJson))
+	}
+	for _, op := range []string{"native", "scalar", "aggr", "collat"} {
+		add("Funcs"+title(op), oneCase("funcs."+op, benchFuncs))
+	}
+	add("StreamSimpleQuery", oneCase("stream.simple.query", benchStreamSimple))
+	add("StreamLargeQuery", intCases([]int{50_000, 100_000, 200_000}, "%06d", "stream.large.%s.query", benchStreamLarge))
+	add("CancelAbort", oneCase("cancel.abort", benchCancel))
+	for _, op := range []string{"auto", "tx"} {
+		var cases []goCase
+		for _, journalMode := range []string{"DELETE", "TRUNCATE", "WAL"} {
+			for _, synchronous := range []string{"OFF", "NORMAL", "FULL"} {
+				name := strings.ToLower(journalMode + "." + synchronous)
+				cases = append(cases, goCase{name, "durable." + name + "." + op, func(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+					benchDurableMode(t, dbfile, journalMode, synchronous, makeDb)
+				}})
+			}
+		}
+		add("Durable"+title(op), cases)
+	}
+	add("PoolQuery", intCases([]int{2, 4, 8}, "%d", "pool.%s.query", benchPool))
+	add("BackupApi", oneCase("backup.api", benchBackup))
+	add("BackupVacuum", oneCase("backup.vacuum", benchBackup))
+	for _, op := range []string{"index", "column", "table", "stats"} {
+		var cases []goCase
+		for _, journalMode := range []string{"DELETE", "WAL"} {
+			name := strings.ToLower(journalMode)
+			cases = append(cases, goCase{name, "migrate." + name + "." + op, func(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+				benchMigrate(t, dbfile, journalMode, makeDb)
+			}})
+		}
+		add("Migrate"+title(op), cases)
+	}
+	add("BulkInsert", nameCases([]string{"rows", "values.0010", "values.0100", "values.0200", "json.01000", "json.10000", "stage"}, "bulk.%s.insert", benchBulk))
+	for _, op := range []string{"update", "replace", "ignore", "return"} {
+		add("Upsert"+title(op), oneCase("upsert."+op, benchUpsert))
+	}
+	add("FkeysInsert", nameCases([]string{"off", "on"}, "fkeys.%s.insert", benchFkeys))
+	add("FkeysDelete", oneCase("fkeys.on.delete", benchFkeys))
+	add("BlobWrite", nameCases([]string{"whole", "chunks"}, "blob.%s.write", benchBlob))
+	add("BlobRead", nameCases([]string{"whole", "chunks"}, "blob.%s.read", benchBlob))
+	for _, op := range []string{"insert", "query", "local", "commit"} {
+		add("Attach"+title(op), oneCase("attach."+op, benchAttach))
+	}
+	for _, strategy := range []string{"offset", "keyset"} {
+		add("Paginate"+title(strategy), intCases(pageSizes, "%04d", "paginate."+strategy+".%s", func(t *timing, dbfile string, _ int, makeDb func(dbfile string) Db) {
+			benchPaginate(t, dbfile, makeDb)
+		}))
+	}
+	return gbs
+}
+
+// title returns s with its first letter in upper case, e.g. "Insert".
+func title(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// BenchLoop is the part of a testing.B that times a section, see package
+// gobench. Package app does not import package testing, which would add
+// the test flags to the bench binaries.
+type BenchLoop interface {
+	Loop() bool
+	StopTimer()
+	StartTimer()
+	Elapsed() time.Duration
+	ReportMetric(n float64, unit string)
+}
+
+// GoBenchmarkCases returns the names of the sub-benchmarks of the Go
+// benchmark name, e.g. "0010", "0100" and "1000" for "ManyQuery", or one
+// empty name if it has none. ok is false if name is unknown.
+func GoBenchmarkCases(name string) (cases []string, ok bool) {
+	for _, gb := range goBenchmarks() {
+		if gb.name == name {
+			for _, c := range gb.cases {
+				cases = append(cases, c.name)
+			}
+			return cases, true
+		}
+	}
+	return nil, false
+}
+
+// RunGoBenchmark runs the case of the Go benchmark name once, and times
+// its section with loop. All other sections are untimed setup and
+// validation, and the result lines are discarded. It reports whether the
+// section was timed, it is not if the driver does not support it.
+func RunGoBenchmark(loop BenchLoop, name, caseName string, dbfile string, makeDb func(dbfile string) Db) bool {
+	for _, gb := range goBenchmarks() {
+		if gb.name != name {
+			continue
+		}
+		for _, c := range gb.cases {
+			if c.name != caseName {
+				continue
+			}
+			output := log.Writer()
+			log.SetOutput(io.Discard)
+			defer log.SetOutput(output)
+			t := &timing{loop: loop, section: c.section}
+			c.run(t, dbfile, makeDb)
+			return t.measured
+		}
+	}
+	panic(fmt.Sprintf("unknown Go benchmark %s/%s", name, caseName))
+}
@@ -0,0 +1,7 @@
+//go:build bvinc
+
+package gobench
+
+import "github.com/cvilsmeier/go-sqlite-bench/drivers/bvinc"
+
+func init() { useDriver(bvinc.New) }
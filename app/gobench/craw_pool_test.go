@@ -0,0 +1,7 @@
+//go:build craw_pool
+
+package gobench
+
+import "github.com/cvilsmeier/go-sqlite-bench/drivers/craw"
+
+func init() { useDriver(craw.NewPool) }
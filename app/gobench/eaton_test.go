@@ -0,0 +1,7 @@
+//go:build eaton
+
+package gobench
+
+import "github.com/cvilsmeier/go-sqlite-bench/drivers/eaton"
+
+func init() { useDriver(eaton.New) }
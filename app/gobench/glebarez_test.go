@@ -0,0 +1,7 @@
+//go:build glebarez
+
+package gobench
+
+import "github.com/cvilsmeier/go-sqlite-bench/drivers/glebarez"
+
+func init() { useDriver(glebarez.New) }
@@ -0,0 +1,46 @@
+// Package gobench runs the benchmarks of package app as Go benchmarks, one
+// per timed section, e.g. BenchmarkSimpleInsert, with the driver selected
+// by a build tag:
+//
+//	go test -tags mattn -bench . -benchmem ./app/gobench
+//
+// The build tags are the driver names, with underscores for "-" and "+",
+// e.g. craw_pool or mattn_sqlx. Package app does not import package
+// testing, so that the bench binaries do not get the test flags.
+package gobench
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+)
+
+// Run runs the Go benchmark name, e.g. "ManyQuery", as b, for the Dbs of
+// makeDb. A benchmark with parameters, e.g. many with 10, 100 and 1000
+// users, runs one sub-benchmark per parameter, named like its results,
+// e.g. "0010". Each (sub-)benchmark runs the benchmark of package app
+// once, all sections but the timed one are untimed setup and validation.
+// Sections that the driver does not support are skipped.
+func Run(b *testing.B, name string, makeDb func(dbfile string) app.Db) {
+	cases, ok := app.GoBenchmarkCases(name)
+	if !ok {
+		b.Fatalf("unknown Go benchmark %q", name)
+	}
+	for _, c := range cases {
+		if c == "" {
+			runCase(b, name, c, makeDb)
+			continue
+		}
+		b.Run(c, func(b *testing.B) {
+			runCase(b, name, c, makeDb)
+		})
+	}
+}
+
+func runCase(b *testing.B, name, caseName string, makeDb func(dbfile string) app.Db) {
+	dbfile := filepath.Join(b.TempDir(), "bench.db")
+	if !app.RunGoBenchmark(b, name, caseName, dbfile, makeDb) {
+		b.Skipf("%s not supported", b.Name())
+	}
+}
@@ -0,0 +1,74 @@
+package gobench
+
+import (
+	"testing"
+
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+)
+
+// makeDb makes the Dbs of the driver that is selected by a build tag, see
+// the driver files, e.g. mattn_test.go.
+var makeDb func(dbfile string) app.Db
+
+// useDriver selects the driver of a build tag.
+func useDriver(newDb func(dbfile string) app.Db) {
+	if makeDb != nil {
+		panic("more than one driver build tag")
+	}
+	makeDb = newDb
+}
+
+func run(b *testing.B, name string) {
+	if makeDb == nil {
+		b.Skip("no driver, select one with a build tag, e.g. -tags mattn")
+	}
+	Run(b, name, makeDb)
+}
+
+func BenchmarkSimpleInsert(b *testing.B)      { run(b, "SimpleInsert") }
+func BenchmarkSimpleQuery(b *testing.B)       { run(b, "SimpleQuery") }
+func BenchmarkRealInsert(b *testing.B)        { run(b, "RealInsert") }
+func BenchmarkRealQuery(b *testing.B)         { run(b, "RealQuery") }
+func BenchmarkComplexInsert(b *testing.B)     { run(b, "ComplexInsert") }
+func BenchmarkComplexQuery(b *testing.B)      { run(b, "ComplexQuery") }
+func BenchmarkManyQuery(b *testing.B)         { run(b, "ManyQuery") }
+func BenchmarkLargeQuery(b *testing.B)        { run(b, "LargeQuery") }
+func BenchmarkConcurrentQuery(b *testing.B)   { run(b, "ConcurrentQuery") }
+func BenchmarkJsonInsert(b *testing.B)        { run(b, "JsonInsert") }
+func BenchmarkJsonExtract(b *testing.B)       { run(b, "JsonExtract") }
+func BenchmarkJsonArrow(b *testing.B)         { run(b, "JsonArrow") }
+func BenchmarkJsonEach(b *testing.B)          { run(b, "JsonEach") }
+func BenchmarkJsonGroup(b *testing.B)         { run(b, "JsonGroup") }
+func BenchmarkJsonIndex(b *testing.B)         { run(b, "JsonIndex") }
+func BenchmarkJsonIdxqry(b *testing.B)        { run(b, "JsonIdxqry") }
+func BenchmarkFuncsNative(b *testing.B)       { run(b, "FuncsNative") }
+func BenchmarkFuncsScalar(b *testing.B)       { run(b, "FuncsScalar") }
+func BenchmarkFuncsAggr(b *testing.B)         { run(b, "FuncsAggr") }
+func BenchmarkFuncsCollat(b *testing.B)       { run(b, "FuncsCollat") }
+func BenchmarkStreamSimpleQuery(b *testing.B) { run(b, "StreamSimpleQuery") }
+func BenchmarkStreamLargeQuery(b *testing.B)  { run(b, "StreamLargeQuery") }
+func BenchmarkCancelAbort(b *testing.B)       { run(b, "CancelAbort") }
+func BenchmarkDurableAuto(b *testing.B)       { run(b, "DurableAuto") }
+func BenchmarkDurableTx(b *testing.B)         { run(b, "DurableTx") }
+func BenchmarkPoolQuery(b *testing.B)         { run(b, "PoolQuery") }
+func BenchmarkBackupApi(b *testing.B)         { run(b, "BackupApi") }
+func BenchmarkBackupVacuum(b *testing.B)      { run(b, "BackupVacuum") }
+func BenchmarkMigrateIndex(b *testing.B)      { run(b, "MigrateIndex") }
+func BenchmarkMigrateColumn(b *testing.B)     { run(b, "MigrateColumn") }
+func BenchmarkMigrateTable(b *testing.B)      { run(b, "MigrateTable") }
+func BenchmarkMigrateStats(b *testing.B)      { run(b, "MigrateStats") }
+func BenchmarkBulkInsert(b *testing.B)        { run(b, "BulkInsert") }
+func BenchmarkUpsertUpdate(b *testing.B)      { run(b, "UpsertUpdate") }
+func BenchmarkUpsertReplace(b *testing.B)     { run(b, "UpsertReplace") }
+func BenchmarkUpsertIgnore(b *testing.B)      { run(b, "UpsertIgnore") }
+func BenchmarkUpsertReturn(b *testing.B)      { run(b, "UpsertReturn") }
+func BenchmarkFkeysInsert(b *testing.B)       { run(b, "FkeysInsert") }
+func BenchmarkFkeysDelete(b *testing.B)       { run(b, "FkeysDelete") }
+func BenchmarkBlobWrite(b *testing.B)         { run(b, "BlobWrite") }
+func BenchmarkBlobRead(b *testing.B)          { run(b, "BlobRead") }
+func BenchmarkAttachInsert(b *testing.B)      { run(b, "AttachInsert") }
+func BenchmarkAttachQuery(b *testing.B)       { run(b, "AttachQuery") }
+func BenchmarkAttachLocal(b *testing.B)       { run(b, "AttachLocal") }
+func BenchmarkAttachCommit(b *testing.B)      { run(b, "AttachCommit") }
+func BenchmarkPaginateOffset(b *testing.B)    { run(b, "PaginateOffset") }
+func BenchmarkPaginateKeyset(b *testing.B)    { run(b, "PaginateKeyset") }
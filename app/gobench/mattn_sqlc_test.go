@@ -0,0 +1,10 @@
+//go:build mattn_sqlc
+
+package gobench
+
+import (
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/mattn"
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/sqlc"
+)
+
+func init() { useDriver(sqlc.Wrap(mattn.New)) }
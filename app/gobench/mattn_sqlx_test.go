@@ -0,0 +1,10 @@
+//go:build mattn_sqlx
+
+package gobench
+
+import (
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/mattn"
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/sqlx"
+)
+
+func init() { useDriver(sqlx.Wrap(mattn.New)) }
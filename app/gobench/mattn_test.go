@@ -0,0 +1,7 @@
+//go:build mattn
+
+package gobench
+
+import "github.com/cvilsmeier/go-sqlite-bench/drivers/mattn"
+
+func init() { useDriver(mattn.New) }
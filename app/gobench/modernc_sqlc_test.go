@@ -0,0 +1,10 @@
+//go:build modernc_sqlc
+
+package gobench
+
+import (
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/modernc"
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/sqlc"
+)
+
+func init() { useDriver(sqlc.Wrap(modernc.New)) }
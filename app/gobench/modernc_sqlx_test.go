@@ -0,0 +1,10 @@
+//go:build modernc_sqlx
+
+package gobench
+
+import (
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/modernc"
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/sqlx"
+)
+
+func init() { useDriver(sqlx.Wrap(modernc.New)) }
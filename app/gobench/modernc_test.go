@@ -0,0 +1,7 @@
+//go:build modernc
+
+package gobench
+
+import "github.com/cvilsmeier/go-sqlite-bench/drivers/modernc"
+
+func init() { useDriver(modernc.New) }
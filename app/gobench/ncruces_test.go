@@ -0,0 +1,7 @@
+//go:build ncruces
+
+package gobench
+
+import "github.com/cvilsmeier/go-sqlite-bench/drivers/ncruces"
+
+func init() { useDriver(ncruces.New) }
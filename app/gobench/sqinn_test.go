@@ -0,0 +1,7 @@
+//go:build sqinn
+
+package gobench
+
+import "github.com/cvilsmeier/go-sqlite-bench/drivers/sqinn"
+
+func init() { useDriver(sqinn.New) }
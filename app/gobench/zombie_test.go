@@ -0,0 +1,7 @@
+//go:build zombie
+
+package gobench
+
+import "github.com/cvilsmeier/go-sqlite-bench/drivers/zombie"
+
+func init() { useDriver(zombie.New) }
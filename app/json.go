@@ -0,0 +1,230 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonDepths are the nesting levels of the profiles of the json benchmark,
+// set with the -jsondepths flag.
+var jsonDepths = []int{1, 8}
+
+// parseJsonDepths parses the -jsondepths flag. SQLite parses JSON of at
+// most 1000 nesting levels, and the tags of a profile add one level.
+func parseJsonDepths(spec string) []int {
+	var values []int
+	for _, s := range strings.Split(spec, ",") {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 999 {
+			log.Fatalf("invalid jsondepth %q, want 1 to 999", s)
+		}
+		values = append(values, n)
+	}
+	return values
+}
+
+// Profile is a JSON document that is stored along with a User.
+// Profiles can be nested, each nesting level holds the same data.
+type Profile struct {
+	Age    int      `json:"age"`
+	City   string   `json:"city"`
+	Tags   []string `json:"tags"`
+	Nested *Profile `json:"nested,omitempty"`
+}
+
+func newProfile(userId int, depth int) *Profile {
+	profile := &Profile{
+		Age:  20 + userId%50,
+		City: cityName(userId % ncities),
+		Tags: []string{tagName(userId % ntags), "common"},
+	}
+	if depth > 1 {
+		profile.Nested = newProfile(userId, depth-1)
+	}
+	return profile
+}
+
+const ncities = 10
+const ntags = 5
+
+func cityName(icity int) string {
+	return fmt.Sprintf("city%02d", icity)
+}
+
+func tagName(itag int) string {
+	return fmt.Sprintf("tag%d", itag)
+}
+
+// profilePath returns the JSON path of a field in the innermost
+// nesting level of a profile, e.g. "$.nested.nested.city".
+func profilePath(depth int, field string) string {
+	return "$" + strings.Repeat(".nested", depth-1) + "." + field
+}
+
+// Insert 100000 users with a JSON profile of N nesting levels.
+// Then query users by json_extract predicates, by the ->> operator and by json_each.
+// Then aggregate profiles with json_group_array.
+// Then add a generated column with an index and query users by that column.
+// This benchmark is used to simulate JSON documents stored in TEXT columns.
+func benchJson(t *timing, dbfile string, depth int, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	db := makeDb(dbfile)
+	defer db.Close()
+	initSchema(db)
+	version := sqliteVersion(db)
+	db.Exec("ALTER TABLE users ADD COLUMN profile TEXT NOT NULL DEFAULT '{}'")
+	// make users with profiles
+	g := newGenerator()
+	created := g.Clock(time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local), time.Minute)
+	nusers := scaled(100_000)
+	params := make([][]any, 0, nusers)
+	emails := make([]string, 0, nusers)
+	for i := range nusers {
+		userId := i + 1
+		data, err := json.Marshal(newProfile(userId, depth))
+		MustBeNil(err)
+		email := g.Email("user%08d@example.com", userId)
+		emails = append(emails, email)
+		params = append(params, []any{
+			userId,              // id
+			BindTime(created()), // created
+			email,               // email
+			true,                // active
+			string(data),        // profile
+		})
+	}
+	// insert users
+	stopProfiles := startProfiles(fmt.Sprintf("json.%02d.insert", depth), db.DriverName())
+	insert := t.measure(fmt.Sprintf("json.%02d.insert", depth), func() {
+		db.Begin()
+		db.ExecParams("INSERT INTO users(id,created,email,active,profile) VALUES(?,?,?,?,?)", params)
+		db.Commit()
+	}, func() {
+		db.Exec("DELETE FROM users")
+	})
+	stopProfiles()
+	if verbose {
+		log.Printf("  insert took %d ms", insert.millis())
+	}
+	cityPath := profilePath(depth, "city")
+	validateCity := func(icity int, users []User) {
+		MustBeEqual(nusers/ncities, len(users))
+		for _, u := range users {
+			MustBeEqual(icity, u.Id%ncities)
+			MustBeEqual(emails[u.Id-1], u.Email)
+			MustBeEqual(true, u.Active)
+		}
+	}
+	// query users by json_extract
+	stopProfiles = startProfiles(fmt.Sprintf("json.%02d.extract", depth), db.DriverName())
+	extract := t.measure(fmt.Sprintf("json.%02d.extract", depth), func() {
+		for icity := range ncities {
+			users := db.FindUsers(fmt.Sprintf("SELECT id,created,email,active FROM users"+
+				" WHERE json_extract(profile, '%s') = '%s' ORDER BY id", cityPath, cityName(icity)))
+			validateCity(icity, users)
+		}
+	}, nil)
+	stopProfiles()
+	// query users by ->> operator, available since SQLite 3.38.0
+	var arrow measurement
+	if version >= 3_038_000 {
+		stopProfiles = startProfiles(fmt.Sprintf("json.%02d.arrow", depth), db.DriverName())
+		arrow = t.measure(fmt.Sprintf("json.%02d.arrow", depth), func() {
+			for icity := range ncities {
+				users := db.FindUsers(fmt.Sprintf("SELECT id,created,email,active FROM users"+
+					" WHERE profile ->> '%s' = '%s' ORDER BY id", cityPath, cityName(icity)))
+				validateCity(icity, users)
+			}
+		}, nil)
+		stopProfiles()
+	}
+	// count users by json_each
+	stopProfiles = startProfiles(fmt.Sprintf("json.%02d.each", depth), db.DriverName())
+	each := t.measure(fmt.Sprintf("json.%02d.each", depth), func() {
+		for itag := range ntags {
+			values := db.QueryStrings("SELECT count(*) FROM users, json_each(users.profile, '$.tags')"+
+				" WHERE json_each.value = ?", []any{tagName(itag)})
+			MustBeEqual(1, len(values))
+			count, err := strconv.Atoi(values[0])
+			MustBeNil(err)
+			MustBeEqual(nusers/ntags, count)
+		}
+	}, nil)
+	stopProfiles()
+	// aggregate profiles by json_group_array
+	stopProfiles = startProfiles(fmt.Sprintf("json.%02d.group", depth), db.DriverName())
+	group := t.measure(fmt.Sprintf("json.%02d.group", depth), func() {
+		for icity := range ncities {
+			values := db.QueryStrings("SELECT json_group_array(json(profile)) FROM users"+
+				" WHERE json_extract(profile, '"+cityPath+"') = ?", []any{cityName(icity)})
+			MustBeEqual(1, len(values))
+			var profiles []*Profile
+			err := json.Unmarshal([]byte(values[0]), &profiles)
+			MustBeNil(err)
+			MustBeEqual(nusers/ncities, len(profiles))
+			for _, profile := range profiles {
+				for range depth - 1 {
+					MustBeSet(profile.Nested)
+					profile = profile.Nested
+				}
+				MustBe(profile.Nested == nil)
+				MustBeEqual(cityName(icity), profile.City)
+				MustBeEqual(2, len(profile.Tags))
+				MustBeEqual("common", profile.Tags[1])
+			}
+		}
+	}, nil)
+	stopProfiles()
+	// index a generated column, available since SQLite 3.31.0, but dropping
+	// it between runs needs SQLite 3.35.0
+	var index, indexed measurement
+	if version >= 3_035_000 {
+		stopProfiles = startProfiles(fmt.Sprintf("json.%02d.index", depth), db.DriverName())
+		index = t.measure(fmt.Sprintf("json.%02d.index", depth), func() {
+			db.Exec(
+				"ALTER TABLE users ADD COLUMN city TEXT GENERATED ALWAYS AS (json_extract(profile, '"+cityPath+"')) VIRTUAL",
+				"CREATE INDEX users_city ON users(city)",
+			)
+		}, func() {
+			db.Exec(
+				"DROP INDEX users_city",
+				"ALTER TABLE users DROP COLUMN city",
+			)
+		})
+		stopProfiles()
+		// query users by generated column
+		stopProfiles = startProfiles(fmt.Sprintf("json.%02d.idxqry", depth), db.DriverName())
+		indexed = t.measure(fmt.Sprintf("json.%02d.idxqry", depth), func() {
+			for icity := range ncities {
+				users := db.FindUsers(fmt.Sprintf("SELECT id,created,email,active FROM users"+
+					" WHERE city = '%s' ORDER BY id", cityName(icity)))
+				validateCity(icity, users)
+			}
+		}, nil)
+		stopProfiles()
+	}
+	// print results
+	bench := fmt.Sprintf("7_json/%02d", depth)
+	logResult(bench, "insert", db.DriverName(), insert)
+	logResult(bench, "extrct", db.DriverName(), extract)
+	if version >= 3_038_000 {
+		logResult(bench, "arrow", db.DriverName(), arrow)
+	} else {
+		logUnsupported(bench, "arrow", db.DriverName())
+	}
+	logResult(bench, "each", db.DriverName(), each)
+	logResult(bench, "group", db.DriverName(), group)
+	if version >= 3_035_000 {
+		logResult(bench, "index", db.DriverName(), index)
+		logResult(bench, "idxqry", db.DriverName(), indexed)
+	} else {
+		logUnsupported(bench, "index", db.DriverName())
+		logUnsupported(bench, "idxqry", db.DriverName())
+	}
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
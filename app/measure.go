@@ -0,0 +1,112 @@
+package app
+
+import (
+	"log"
+	"time"
+)
+
+// benchDuration is the time budget of each timed operation, set with the
+// -duration flag. If it is 0, each operation runs once.
+var benchDuration time.Duration
+
+// measurement is the outcome of running a timed operation n times.
+type measurement struct {
+	n       int           // number of runs
+	elapsed time.Duration // time spent in all runs, without resets
+}
+
+// perOp returns the time of one run.
+func (m measurement) perOp() time.Duration {
+	return m.elapsed / time.Duration(m.n)
+}
+
+// millis returns the time of one run in milliseconds.
+func (m measurement) millis() int64 {
+	return m.perOp().Milliseconds()
+}
+
+// timing is how one run of a benchmark times its sections. The zero timing
+// times every section with measure, see Run. The timing of RunGoBenchmark
+// times one section with the BenchLoop of a Go benchmark instead.
+type timing struct {
+	loop     BenchLoop // or nil
+	section  string    // the section that loop times, e.g. "many.0010.query"
+	measured bool      // whether loop timed the section
+}
+
+// loopOf returns the loop that times section, or nil if it is timed by
+// measure.
+func (t *timing) loopOf(section string) BenchLoop {
+	if t.loop == nil || section != t.section {
+		return nil
+	}
+	t.measured = true
+	return t.loop
+}
+
+// measure runs op of section once, the section is named like its profiles,
+// e.g. "simple.insert", see startProfiles. With -duration, it runs op
+// repeatedly, until the runs took at least benchDuration, and grows the
+// number of runs like testing.B grows b.N: each round predicts the number
+// of runs needed from the previous round. Only the runs of the last round
+// are measured.
+// If reset is not nil, it runs before every run but the first, untimed,
+// and must undo what op did, e.g. delete the rows that op inserted.
+// The section of a Go benchmark runs with its BenchLoop instead.
+func (t *timing) measure(section string, op func(), reset func()) measurement {
+	if loop := t.loopOf(section); loop != nil {
+		return measureLoop(loop, op, reset)
+	}
+	dirty := false
+	run := func(n int) measurement {
+		m := measurement{n: n}
+		for range n {
+			if dirty && reset != nil {
+				reset()
+			}
+			t0 := time.Now()
+			op()
+			m.elapsed += time.Since(t0)
+			dirty = true
+		}
+		return m
+	}
+	m := run(1)
+	for benchDuration > 0 && m.elapsed < benchDuration && m.n < 1e9 {
+		// like testing.B: predict the runs needed, 20% more, but grow
+		// at least by one and at most 100 times
+		n := int(float64(benchDuration) * float64(m.n) / float64(max(m.elapsed, 1)))
+		n += n / 5
+		n = min(n, 100*m.n)
+		n = max(n, m.n+1)
+		m = run(n)
+	}
+	return m
+}
+
+// measureLoop measures op with the loop of a Go benchmark, like measure
+// does with -duration.
+func measureLoop(loop BenchLoop, op func(), reset func()) measurement {
+	n := 0
+	for loop.Loop() {
+		if n > 0 && reset != nil {
+			loop.StopTimer()
+			reset()
+			loop.StartTimer()
+		}
+		op()
+		n++
+	}
+	return measurement{n: n, elapsed: loop.Elapsed()}
+}
+
+// logResult prints the result line of a timed operation, in milliseconds
+// per run, or, with -duration, in runs per second and nanoseconds per run.
+func logResult(bench, op, driverName string, m measurement) {
+	if benchDuration == 0 {
+		logValue(bench, op, driverName, m.millis())
+		return
+	}
+	opsPerSec := float64(m.n) / m.elapsed.Seconds()
+	log.Printf("%s - %14.2f ops/s - %12d ns/op - %8d runs", resultLine(bench, op, driverName), opsPerSec, m.perOp().Nanoseconds(), m.n)
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"slices"
+	"strings"
+)
+
+// Insert the users, articles and comments of the complex benchmark.
+// Then migrate the comments table, like services do at startup:
+// create an index on comments(text), add a column with a default value,
+// change the type of that column with the 12-step table rebuild of
+// https://www.sqlite.org/lang_altertable.html#otheralter and run ANALYZE.
+// Do this for journal modes DELETE and WAL.
+// Verify the schema afterwards, from sqlite_schema.
+// This benchmark is used to simulate schema migrations.
+func benchMigrate(t *timing, dbfile, journalMode string, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	// foreign_keys applies to one connection only
+	db := makeOneConnDb(dbfile, makeDb)
+	defer db.Close()
+	initSchema(db)
+	db.Exec("PRAGMA journal_mode=" + journalMode)
+	// read back the effective mode, in-memory databases cannot use WAL
+	values := db.QueryStrings("PRAGMA journal_mode", nil)
+	MustBeEqual(1, len(values))
+	effectiveJournalMode := strings.ToLower(values[0])
+	bench := "15_migrate/" + effectiveJournalMode
+	if sqliteVersion(db) < 3_035_000 {
+		// sqlite_schema needs 3.33.0, DROP COLUMN (to reset column) 3.35.0
+		for _, op := range []string{"index", "column", "table", "stats"} {
+			logUnsupported(bench, op, db.DriverName())
+		}
+		return
+	}
+	users, articles, comments := makeComplex(scaled(200), scaled(100), scaled(20))
+	insertComplex(db, users, articles, comments)
+	// create index on a populated table
+	name := "migrate." + effectiveJournalMode + "."
+	stopProfiles := startProfiles(name+"index", db.DriverName())
+	index := t.measure(name+"index", func() {
+		db.Exec("CREATE INDEX comments_text ON comments(text)")
+	}, func() {
+		db.Exec("DROP INDEX comments_text")
+	})
+	stopProfiles()
+	// add column with a default value, which changes only the schema
+	stopProfiles = startProfiles(name+"column", db.DriverName())
+	column := t.measure(name+"column", func() {
+		db.Exec("ALTER TABLE comments ADD COLUMN score INTEGER NOT NULL DEFAULT 0")
+	}, func() {
+		db.Exec("ALTER TABLE comments DROP COLUMN score")
+	})
+	stopProfiles()
+	// change the type of score from INTEGER to REAL, with a table rebuild,
+	// which can run again on the rebuilt table
+	stopProfiles = startProfiles(name+"table", db.DriverName())
+	table := t.measure(name+"table", func() {
+		db.Exec("PRAGMA foreign_keys=0")
+		db.Begin()
+		db.Exec(
+			"CREATE TABLE comments_new ("+
+				"id INTEGER PRIMARY KEY NOT NULL,"+
+				" created INTEGER NOT NULL, "+ // time.Time
+				" articleId INTEGER NOT NULL REFERENCES articles(id),"+
+				" text TEXT NOT NULL,"+
+				" score REAL NOT NULL DEFAULT 0)",
+			"INSERT INTO comments_new(id,created,articleId,text,score)"+
+				" SELECT id,created,articleId,text,score FROM comments",
+			"DROP TABLE comments",
+			"ALTER TABLE comments_new RENAME TO comments",
+			"CREATE INDEX comments_created ON comments(created)",
+			"CREATE INDEX comments_articleId ON comments(articleId)",
+			"CREATE INDEX comments_text ON comments(text)",
+		)
+		values := db.QueryStrings("PRAGMA foreign_key_check(comments)", nil)
+		MustBeEqual(0, len(values))
+		db.Commit()
+		db.Exec("PRAGMA foreign_keys=1")
+	}, nil)
+	stopProfiles()
+	// gather statistics for the query planner
+	stopProfiles = startProfiles(name+"stats", db.DriverName())
+	stats := t.measure(name+"stats", func() {
+		db.Exec("ANALYZE")
+	}, nil)
+	stopProfiles()
+	// validate
+	values = db.QueryStrings("SELECT sql FROM sqlite_schema WHERE type='table' AND name='comments'", nil)
+	MustBeEqual(1, len(values))
+	Must(strings.Contains(values[0], "score REAL NOT NULL DEFAULT 0"), "comments.score must be REAL")
+	values = db.QueryStrings("SELECT name FROM sqlite_schema WHERE type='index' AND tbl_name='comments' ORDER BY name", nil)
+	MustBeEqual("comments_articleId,comments_created,comments_text", strings.Join(values, ","))
+	values = db.QueryStrings("SELECT name FROM sqlite_schema WHERE type='table' ORDER BY name", nil)
+	Must(slices.Contains(values, "sqlite_stat1"), "ANALYZE must create sqlite_stat1")
+	Must(!slices.Contains(values, "comments_new"), "comments_new must be renamed")
+	MustBeEqual(int64(len(comments)), queryInt64(db, "SELECT count(*) FROM comments"))
+	values = db.QueryStrings("PRAGMA integrity_check", nil)
+	MustBeEqual(1, len(values))
+	MustBeEqual("ok", values[0])
+	// print results
+	logResult(bench, "index", db.DriverName(), index)
+	logResult(bench, "column", db.DriverName(), column)
+	logResult(bench, "table", db.DriverName(), table)
+	logResult(bench, "stats", db.DriverName(), stats)
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
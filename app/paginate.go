@@ -0,0 +1,127 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pageSizes are the rows per page of the paginate benchmark, set with the
+// -pagesizes flag.
+var pageSizes = []int{50, 1000}
+
+// parsePageSizes parses the -pagesizes flag.
+func parsePageSizes(spec string) []int {
+	var values []int
+	for _, s := range strings.Split(spec, ",") {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			log.Fatalf("invalid pagesize %q", s)
+		}
+		values = append(values, n)
+	}
+	return values
+}
+
+// Insert the users, articles and comments of the complex benchmark.
+// Then walk all comments page by page, ordered by their created time, with
+// the article and user of each comment, once with LIMIT/OFFSET (offset)
+// and once with keyset pagination, WHERE created > the created time of the
+// last comment of the previous page (keyset), for each page size.
+// The created times of comments are unique in the dataset.
+// Besides the total time of each walk, the mean latency of a page in
+// microseconds is reported for each tenth of the walk (d01 to d10).
+// OFFSET skips all rows of the previous pages for every page, so its
+// latency grows with each page, and its total time with the square of the
+// number of pages. Keyset latency stays flat, which shows the per-query
+// overhead of the driver for small pages.
+// This benchmark is used to simulate list endpoints.
+func benchPaginate(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	db := makeDb(dbfile)
+	defer db.Close()
+	initSchema(db)
+	users, articles, comments := makeComplex(scaled(200), scaled(100), scaled(20))
+	insertComplex(db, users, articles, comments)
+	const selectSql = "SELECT" +
+		" users.id, users.created, users.email, users.active," +
+		" articles.id, articles.created, articles.userId, articles.text," +
+		" comments.id, comments.created, comments.articleId, comments.text" +
+		" FROM comments" +
+		" JOIN articles ON articles.id = comments.articleId" +
+		" JOIN users ON users.id = articles.userId"
+	strategies := []struct {
+		name      string
+		querySql  string
+		pageParam func(page int, pageSize int, last Comment) []any
+	}{
+		{
+			"offset",
+			selectSql + " ORDER BY comments.created LIMIT ? OFFSET ?",
+			func(page int, pageSize int, _ Comment) []any {
+				return []any{pageSize, page * pageSize}
+			},
+		},
+		{
+			"keyset",
+			selectSql + " WHERE comments.created > ? ORDER BY comments.created LIMIT ?",
+			func(_ int, pageSize int, last Comment) []any {
+				return []any{BindTime(last.Created), pageSize}
+			},
+		},
+	}
+	npages := 0
+	for _, pageSize := range pageSizes {
+		for _, strategy := range strategies {
+			// walk all pages, until a page is not full
+			var found []Comment
+			var latencies []time.Duration
+			stopProfiles := startProfiles(fmt.Sprintf("paginate.%s.%04d", strategy.name, pageSize), db.DriverName())
+			total := t.measure(fmt.Sprintf("paginate.%s.%04d", strategy.name, pageSize), func() {
+				found = found[:0]
+				latencies = latencies[:0]
+				var last Comment
+				for page := 0; ; page++ {
+					t0 := time.Now()
+					_, _, pageComments := db.FindUsersArticlesComments(strategy.querySql, strategy.pageParam(page, pageSize, last))
+					latencies = append(latencies, time.Since(t0))
+					found = append(found, pageComments...)
+					if len(pageComments) < pageSize {
+						break
+					}
+					last = pageComments[len(pageComments)-1]
+				}
+			}, nil)
+			stopProfiles()
+			// validate pages
+			MustBeEqual(len(comments), len(found))
+			for i, c := range found {
+				MustBeEqual(comments[i], c)
+			}
+			npages = len(latencies)
+			// print results
+			bench := fmt.Sprintf("21_paginate/%s/%04d", strategy.name, pageSize)
+			logResult(bench, "total", db.DriverName(), total)
+			for d := range 10 {
+				tenth := latencies[d*len(latencies)/10 : (d+1)*len(latencies)/10]
+				var sum time.Duration
+				for _, l := range tenth {
+					sum += l
+				}
+				var mean int64
+				if len(tenth) > 0 {
+					mean = sum.Microseconds() / int64(len(tenth))
+				}
+				logValue(bench, fmt.Sprintf("d%02d", d+1), db.DriverName(), mean)
+			}
+		}
+		if verbose {
+			log.Printf("  %d comments, %d pages of %d comments", len(comments), npages, pageSize)
+		}
+	}
+	bench := "21_paginate"
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Insert one million users.
+// Then have N goroutines query all users, sharing one Db and its pool of
+// connections, like most Go services share one *sql.DB.
+// The pool holds N connections, unless the -maxopen flag says otherwise.
+// This benchmark is the shared-pool variant of benchConcurrent, where each
+// goroutine has a Db of its own.
+func benchPool(t *timing, dbfile string, ngoroutines int, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	saved := poolConfig
+	if poolConfig.MaxOpenConns == 0 {
+		poolConfig.MaxOpenConns = ngoroutines
+	}
+	db := makeDb(dbfile)
+	poolConfig = saved
+	defer db.Close()
+	bench := fmt.Sprintf("13_pool/%d", ngoroutines)
+	pdb, ok := db.(PoolDb)
+	if !ok || exclusiveLocking() { // an exclusive lock allows one connection only
+		logUnsupported(bench, "query", db.DriverName())
+		return
+	}
+	initSchema(db)
+	// insert many users
+	g := newGenerator()
+	created := g.Clock(time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local), time.Second)
+	nusers := scaled(1_000_000)
+	var users []User
+	for i := range nusers {
+		users = append(users, NewUser(
+			i+1,                                // Id
+			created(),                          // Created
+			g.Email("user%d@example.com", i+1), // Email
+			true,                               // Active
+		))
+	}
+	db.Begin()
+	db.InsertUsers(insertUserSql, users)
+	db.Commit()
+	// query users in N goroutines
+	stopProfiles := startProfiles(fmt.Sprintf("pool.%d.query", ngoroutines), db.DriverName())
+	query := t.measure(fmt.Sprintf("pool.%d.query", ngoroutines), func() {
+		var wg sync.WaitGroup
+		for range ngoroutines {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				found := db.FindUsers("SELECT id,created,email,active FROM users ORDER BY id")
+				MustBeEqual(nusers, len(found))
+				// validate query result
+				for i, u := range found {
+					MustBeEqual(users[i], u)
+				}
+			}()
+		}
+		// wait for completion
+		wg.Wait()
+	}, nil)
+	stopProfiles()
+	if verbose {
+		log.Printf("  query took %d ms", query.millis())
+	}
+	// print results
+	logResult(bench, "query", db.DriverName(), query)
+	logValue(bench, "conns", db.DriverName(), pdb.PoolSize())
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// tunablePragmas are the pragmas that can be set by a pragma profile,
+// in the order they are applied. page_size comes first since it must be
+// set before the database file is written.
+var tunablePragmas = []string{"page_size", "cache_size", "mmap_size", "temp_store", "locking_mode"}
+
+// pragmaProfiles are named sets of pragmas, selected with the -pragmas flag.
+// Pragmas not listed in a profile keep the SQLite (compile-time) default.
+var pragmaProfiles = map[string]map[string]string{
+	"default": {},
+	"cache": {
+		"cache_size": "-65536", // 64 MiB
+		"temp_store": "MEMORY",
+	},
+	"mmap": {
+		"cache_size": "-65536",     // 64 MiB
+		"mmap_size":  "1073741824", // 1 GiB
+		"temp_store": "MEMORY",
+	},
+	"large": {
+		"page_size":  "16384",
+		"cache_size": "-65536",     // 64 MiB
+		"mmap_size":  "1073741824", // 1 GiB
+		"temp_store": "MEMORY",
+	},
+	"exclusive": {
+		"locking_mode": "EXCLUSIVE",
+	},
+}
+
+// tunedPragmas are the PRAGMA statements of the selected profile and
+// overrides, see applyPragmaProfile. They are executed by initSchema and
+// whenever a benchmark opens another connection.
+var tunedPragmas []string
+
+// pragmaProfileNames returns the names of all pragma profiles, sorted.
+func pragmaProfileNames() []string {
+	var names []string
+	for name := range pragmaProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyPragmaProfile selects a pragma profile and applies overrides,
+// an override of "" keeps the value of the profile.
+func applyPragmaProfile(profileName string, overrides map[string]string) {
+	profile, ok := pragmaProfiles[profileName]
+	if !ok {
+		log.Fatalf("unknown pragma profile %q, want one of %s", profileName, strings.Join(pragmaProfileNames(), ","))
+	}
+	tunedPragmas = nil
+	for _, name := range tunablePragmas {
+		value := profile[name]
+		if overrides[name] != "" {
+			value = overrides[name]
+		}
+		if value != "" {
+			tunedPragmas = append(tunedPragmas, fmt.Sprintf("PRAGMA %s=%s", name, value))
+		}
+	}
+}
+
+// effectivePragmas reads back the tunable pragmas from db, e.g.
+// "page_size=4096 cache_size=-2000 mmap_size=0 temp_store=0 locking_mode=normal".
+// SQLite silently ignores values it cannot apply, so results must be tagged
+// with what the connection reports, not with what was requested.
+func effectivePragmas(db Db) string {
+	parts := make([]string, 0, len(tunablePragmas))
+	for _, name := range tunablePragmas {
+		values := db.QueryStrings("PRAGMA "+name, nil)
+		if len(values) == 0 {
+			values = []string{"n/a"} // e.g. mmap_size for in-memory databases
+		}
+		parts = append(parts, name+"="+values[0])
+	}
+	return strings.Join(parts, " ")
+}
+
+// exclusiveLocking reports whether the tuned pragmas set locking_mode to
+// EXCLUSIVE. The first connection that reads the database file keeps its
+// lock then, so a second connection cannot use the file.
+func exclusiveLocking() bool {
+	for _, pragma := range tunedPragmas {
+		if strings.EqualFold(pragma, "PRAGMA locking_mode=EXCLUSIVE") {
+			return true
+		}
+	}
+	return false
+}
+
+// connPragmas returns the pragmas that apply to a connection, not to the
+// database file, see OpenSqlDb.
+func connPragmas() []string {
+	pragmas := []string{
+		"PRAGMA foreign_keys=1",
+		"PRAGMA busy_timeout=5000", // 5s busy timeout
+	}
+	return append(pragmas, tunedPragmas...)
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const writerRowsPerTx = 10
+
+// writerInsertSql inserts writerRowsPerTx users in one statement, and
+// therefore in one (autocommit) transaction, so that a failed transaction
+// needs no rollback.
+var writerInsertSql = "INSERT INTO users(id,created,email,active) VALUES" +
+	strings.Repeat("(?,?,?,?),", writerRowsPerTx-1) + "(?,?,?,?)"
+
+// writerResult is what a writer process reports back.
+type writerResult struct {
+	ntx     int           // transactions committed
+	nfailed int           // transactions failed, e.g. because busy_timeout expired
+	total   time.Duration // time spent in all transactions
+	fastest time.Duration // time spent in the fastest transaction
+	start   time.Time
+	end     time.Time
+}
+
+// Insert users from N processes into one database, each process commits
+// 200 transactions of 10 users each, with a busy timeout of 5s.
+// The processes are the bench binary itself, started with the -writer flag.
+// This benchmark is used to simulate a web server and cron jobs writing
+// to the same database file.
+// The results are committed transactions per second (aggregated over all
+// processes), the estimated busy-wait time in millis (the time each
+// transaction took longer than the fastest transaction of its process,
+// summed up) and the number of failed transactions.
+// The work is fixed, -duration does not apply.
+func benchProcs(dbfile string, journalMode string, nprocs int, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	db := makeDb(dbfile)
+	driverName := db.DriverName()
+	bench := fmt.Sprintf("12_procs/%s/%d", strings.ToLower(journalMode), nprocs)
+	if isMemoryDbfile(dbfile) || exclusiveLocking() {
+		// other processes cannot see in-memory databases, nor get a lock
+		// on an exclusively locked file
+		db.Close()
+		logUnsupported(bench, "txps", driverName)
+		return
+	}
+	initSchema(db)
+	db.Exec("PRAGMA journal_mode=" + journalMode) // WAL is persistent, DELETE is the default anyway
+	db.Close()
+	// start writer processes, they start writing at the same time
+	executable, err := os.Executable()
+	MustBeNil(err)
+	ntx := scaled(200)
+	startAt := time.Now().Add(500 * time.Millisecond)
+	cmds := make([]*exec.Cmd, nprocs)
+	outs := make([]*bytes.Buffer, nprocs)
+	for i := range nprocs {
+		spec := fmt.Sprintf("%d:%d:%d", i+1, ntx, startAt.UnixNano())
+		args := append([]string{"-writer", spec}, writerFlags()...)
+		cmds[i] = exec.Command(executable, append(args, dbfile)...)
+		outs[i] = new(bytes.Buffer)
+		cmds[i].Stdout = outs[i]
+		cmds[i].Stderr = os.Stderr
+		MustBeNil(cmds[i].Start())
+	}
+	// collect results
+	var committed, failed int
+	var wait time.Duration
+	var first, last time.Time
+	for i, cmd := range cmds {
+		err := cmd.Wait()
+		Must(err == nil, "writer %d: %v\n%s", i+1, err, outs[i].String())
+		r := parseWriterResult(outs[i].String())
+		committed += r.ntx
+		failed += r.nfailed
+		wait += r.total - time.Duration(r.ntx+r.nfailed)*r.fastest
+		if first.IsZero() || r.start.Before(first) {
+			first = r.start
+		}
+		if r.end.After(last) {
+			last = r.end
+		}
+	}
+	elapsed := last.Sub(first)
+	if verbose {
+		log.Printf("  %d writers took %d ms", nprocs, elapsed.Milliseconds())
+	}
+	// validate
+	db = makeDb(dbfile)
+	defer db.Close()
+	values := db.QueryStrings("SELECT count(*) FROM users", nil)
+	MustBeEqual(1, len(values))
+	MustBeEqual(strconv.Itoa(committed*writerRowsPerTx), values[0])
+	MustBeEqual(nprocs*ntx, committed+failed)
+	// print results
+	logValue(bench, "txps", driverName, int64(float64(committed)/elapsed.Seconds()))
+	if !verifyMode {
+		// with one transaction per writer, as in verify mode, wait is always 0
+		logValue(bench, "wait", driverName, wait.Milliseconds())
+	}
+	logValue(bench, "failed", driverName, failed)
+	logValue(bench, "dbsize", driverName, dbsize(dbfile))
+}
+
+// writerFlags returns the flags that make a writer process use the
+// database like this process: the dataset, the tuned pragmas and the pool
+// configuration.
+func writerFlags() []string {
+	flags := []string{
+		"-dataset=" + dataset,
+		"-seed=" + strconv.FormatUint(dataSeed, 10),
+		"-maxopen=" + strconv.Itoa(poolConfig.MaxOpenConns),
+		"-maxidle=" + strconv.Itoa(poolConfig.MaxIdleConns),
+		"-maxlifetime=" + poolConfig.ConnMaxLifetime.String(),
+	}
+	for _, pragma := range tunedPragmas {
+		flags = append(flags, "-"+strings.TrimPrefix(pragma, "PRAGMA ")) // e.g. -cache_size=-2000
+	}
+	return flags
+}
+
+// runWriter is the main function of a writer process, see benchProcs.
+// The spec is "id:ntx:startUnixNano".
+func runWriter(dbfile string, spec string, makeDb func(dbfile string) Db) {
+	var id, ntx int
+	var startNanos int64
+	_, err := fmt.Sscanf(spec, "%d:%d:%d", &id, &ntx, &startNanos)
+	MustBeNil(err)
+	db := makeDb(dbfile)
+	defer db.Close()
+	db.Exec(
+		"PRAGMA busy_timeout=5000", // 5s busy timeout, first, since other processes are writing already
+		"PRAGMA synchronous=FULL",
+	)
+	db.Exec(tunedPragmas...)
+	g := newGenerator()
+	base := time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local)
+	created := g.Clock(base.Add(time.Duration(id*1_000_000+1)*time.Second), time.Second)
+	time.Sleep(time.Until(time.Unix(0, startNanos)))
+	r := writerResult{start: time.Now()}
+	for itx := range ntx {
+		params := make([]any, 0, 4*writerRowsPerTx)
+		for irow := range writerRowsPerTx {
+			userId := id*1_000_000 + itx*writerRowsPerTx + irow + 1
+			params = append(params,
+				userId,                                  // id
+				BindTime(created()),                     // created
+				g.Email("user%08d@example.com", userId), // email
+				true,                                    // active
+			)
+		}
+		t0 := time.Now()
+		err := db.TryExecParams(writerInsertSql, [][]any{params})
+		took := time.Since(t0)
+		if err != nil {
+			r.nfailed++
+		} else {
+			r.ntx++
+		}
+		r.total += took
+		if r.fastest == 0 || took < r.fastest {
+			r.fastest = took
+		}
+	}
+	r.end = time.Now()
+	fmt.Printf("writer %d %d %d %d %d %d\n", r.ntx, r.nfailed, r.total, r.fastest, r.start.UnixNano(), r.end.UnixNano())
+}
+
+func parseWriterResult(out string) writerResult {
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		var r writerResult
+		var startNanos, endNanos int64
+		_, err := fmt.Sscanf(scanner.Text(), "writer %d %d %d %d %d %d", &r.ntx, &r.nfailed, &r.total, &r.fastest, &startNanos, &endNanos)
+		if err == nil {
+			r.start = time.Unix(0, startNanos)
+			r.end = time.Unix(0, endNanos)
+			return r
+		}
+	}
+	panic(fmt.Sprintf("no writer result in output:\n%s", out))
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// profile flags, see Run
+var (
+	profileDir   = "."
+	cpuProfile   bool
+	memProfile   bool
+	blockProfile bool
+	traceProfile bool
+)
+
+// startProfiles starts the profiles that are selected by flags, for one
+// timed section of a benchmark. The returned func stops them and must be
+// called at the end of the section.
+// Profiles are written to profileDir, into files named like
+// "simple.insert.mattn.cpu.pprof". Heap and block profiles are cumulative,
+// so for these a base profile is written at the start of the section, and
+// "go tool pprof -base" shows what happened in between.
+func startProfiles(name string, driverName string) func() {
+	prefix := filepath.Join(profileDir, name+"."+driverName)
+	var stops []func()
+	if memProfile {
+		runtime.GC() // heap profile is updated by the garbage collector
+		writeProfile("allocs", prefix+".mem.base.pprof")
+		stops = append(stops, func() {
+			runtime.GC()
+			writeProfile("allocs", prefix+".mem.pprof")
+		})
+	}
+	if blockProfile {
+		runtime.SetBlockProfileRate(1)
+		writeProfile("block", prefix+".block.base.pprof")
+		stops = append(stops, func() {
+			runtime.SetBlockProfileRate(0)
+			writeProfile("block", prefix+".block.pprof")
+		})
+	}
+	if cpuProfile {
+		f := createProfileFile(prefix + ".cpu.pprof")
+		MustBeNil(pprof.StartCPUProfile(f))
+		stops = append(stops, func() {
+			pprof.StopCPUProfile()
+			MustBeNil(f.Close())
+		})
+	}
+	if traceProfile {
+		f := createProfileFile(prefix + ".trace")
+		MustBeNil(trace.Start(f))
+		stops = append(stops, func() {
+			trace.Stop()
+			MustBeNil(f.Close())
+		})
+	}
+	return func() {
+		for i := len(stops) - 1; i >= 0; i-- {
+			stops[i]()
+		}
+	}
+}
+
+func writeProfile(profileName string, filename string) {
+	f := createProfileFile(filename)
+	MustBeNil(pprof.Lookup(profileName).WriteTo(f, 0))
+	MustBeNil(f.Close())
+}
+
+func createProfileFile(filename string) *os.File {
+	MustBeNil(os.MkdirAll(filepath.Dir(filename), 0o755))
+	f, err := os.Create(filename)
+	MustBeNil(err)
+	return f
+}
@@ -1,43 +1,211 @@
 package app
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"io"
+	"iter"
+	"time"
 )
 
 // SqlDb is a Db implementation that uses database/sql package.
 type SqlDb struct {
 	driverName string
 	db         *sql.DB
-	tx         *sql.Tx // or nil if no tx active right now
+	conn       *sql.Conn // of tx
+	tx         *sql.Tx   // or nil if no tx active right now
 }
 
-var _ Db = (*SqlDb)(nil)
+var _ PoolDb = (*SqlDb)(nil)
 
+// PoolConfig configures the database/sql connection pool of a SqlDb, the
+// fields are passed to the setters of sql.DB. A zero MaxOpenConns means
+// unlimited open connections, a zero MaxIdleConns means no idle connections
+// and a zero ConnMaxLifetime means connections are reused forever. The
+// zero PoolConfig is not the default, see poolConfig.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// poolConfig is applied to every SqlDb, see Run. The default is the
+// database/sql default: unlimited open connections, 2 idle connections,
+// connections are reused forever.
+var poolConfig = PoolConfig{MaxIdleConns: 2}
+
+// NewSqlDb makes a SqlDb for db and applies the pool config to it.
+// Use OpenSqlDb, so that per-connection pragmas apply to all connections.
 func NewSqlDb(driverName string, db *sql.DB) *SqlDb {
-	return &SqlDb{driverName, db, nil}
+	db.SetMaxOpenConns(poolConfig.MaxOpenConns)
+	db.SetMaxIdleConns(poolConfig.MaxIdleConns)
+	db.SetConnMaxLifetime(poolConfig.ConnMaxLifetime)
+	if privateMemory {
+		db.SetMaxOpenConns(1) // a second connection would see another database
+	}
+	if exclusiveLocking() {
+		db.SetMaxOpenConns(1) // a second connection would not get a lock
+	}
+	return &SqlDb{driverName, db, nil, nil}
+}
+
+// PoolSize returns the number of connections for pools of drivers that
+// are not database/sql drivers: the max open connections of the pool
+// config, or 1 if that is unlimited, the database is private in-memory, or
+// locked exclusively.
+func PoolSize() int {
+	if poolConfig.MaxOpenConns == 0 || privateMemory || exclusiveLocking() {
+		return 1
+	}
+	return poolConfig.MaxOpenConns
+}
+
+// makeOneConnDb makes a Db with a pool of one connection, that is never
+// closed, for benchmarks that set per-connection state, e.g. ATTACH or
+// PRAGMA synchronous and foreign_keys, which other connections of a pool
+// would not see.
+func makeOneConnDb(dbfile string, makeDb func(dbfile string) Db) Db {
+	saved := poolConfig
+	poolConfig = PoolConfig{MaxOpenConns: 1, MaxIdleConns: 1}
+	defer func() { poolConfig = saved }()
+	return makeDb(dbfile)
+}
+
+// OpenSqlDb makes a SqlDb that opens connections with connector.
+// Every new connection executes the per-connection pragmas (foreign_keys,
+// busy_timeout and the pragma profile) before it is handed out by the pool,
+// so they apply to all connections, not only to the one that happened to
+// execute initSchema.
+func OpenSqlDb(driverName string, connector driver.Connector) *SqlDb {
+	return NewSqlDb(driverName, sql.OpenDB(&initConnector{connector}))
+}
+
+// SqlDriver returns the database/sql driver that is registered under name.
+func SqlDriver(name string) driver.Driver {
+	db, err := sql.Open(name, "")
+	MustBeNil(err)
+	defer db.Close()
+	return db.Driver()
+}
+
+// NewConnector returns a connector that opens connections to dsn with drv.
+func NewConnector(drv driver.Driver, dsn string) driver.Connector {
+	return &dsnConnector{drv, dsn}
+}
+
+type dsnConnector struct {
+	drv driver.Driver
+	dsn string
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.drv.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.drv
+}
+
+// initConnector executes connPragmas on every new connection.
+type initConnector struct {
+	driver.Connector
+}
+
+func (c *initConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range connPragmas() {
+		if err = execConn(ctx, conn, s); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func execConn(ctx context.Context, conn driver.Conn, execSql string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, execSql, nil)
+		return err
+	}
+	stmt, err := conn.Prepare(execSql)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	if execer, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = execer.ExecContext(ctx, nil)
+		return err
+	}
+	_, err = stmt.Exec(nil)
+	return err
 }
 
 func (d *SqlDb) DriverName() string {
 	return d.driverName
 }
 
+func (d *SqlDb) PoolSize() int {
+	return d.db.Stats().MaxOpenConnections
+}
+
+// DB returns the database/sql handle, for layers on top of SqlDb.
+func (d *SqlDb) DB() *sql.DB {
+	return d.db
+}
+
+// Tx returns the transaction started by Begin, or nil.
+func (d *SqlDb) Tx() *sql.Tx {
+	return d.tx
+}
+
 func (d *SqlDb) Exec(sqls ...string) {
 	for _, s := range sqls {
-		_, err := d.db.Exec(s)
+		var err error
+		if d.tx != nil {
+			_, err = d.tx.Exec(s)
+		} else {
+			_, err = d.db.Exec(s)
+		}
 		MustBeNil(err)
 	}
 }
 
+// Begin starts the transaction on a connection that it holds until Commit,
+// so that the driver connection of the transaction can be reached, e.g. for
+// incremental blob I/O, which database/sql lacks.
 func (d *SqlDb) Begin() {
-	tx, err := d.db.Begin()
+	conn, err := d.db.Conn(context.Background())
+	MustBeNil(err)
+	tx, err := conn.BeginTx(context.Background(), nil)
 	MustBeNil(err)
-	d.tx = tx
+	d.conn, d.tx = conn, tx
 }
 
 func (d *SqlDb) Commit() {
 	err := d.tx.Commit()
 	MustBeNil(err)
-	d.tx = nil
+	err = d.conn.Close()
+	MustBeNil(err)
+	d.conn, d.tx = nil, nil
+}
+
+// Raw runs f with the driver connection of the transaction started by
+// Begin, if one is active, or of a connection of the pool, like
+// sql.Conn.Raw does. It is for driver APIs that database/sql lacks.
+func (d *SqlDb) Raw(f func(driverConn any) error) error {
+	if d.conn != nil {
+		return d.conn.Raw(f)
+	}
+	conn, err := d.db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(f)
 }
 
 func (d *SqlDb) InsertUsers(insertSql string, users []User) {
@@ -89,6 +257,28 @@ func (d *SqlDb) FindUsers(querySql string) []User {
 	return users
 }
 
+func (d *SqlDb) StreamUsers(querySql string) iter.Seq[*User] {
+	return func(yield func(*User) bool) {
+		rows, err := d.db.Query(querySql)
+		MustBeNil(err)
+		defer rows.Close()
+		var id sql.NullInt32
+		var created sql.NullInt64
+		var email sql.NullString
+		var active sql.NullBool
+		var user User
+		for rows.Next() {
+			err = rows.Scan(&id, &created, &email, &active)
+			MustBeNil(err)
+			user = NewUser(int(id.Int32), UnbindTime(created.Int64), email.String, active.Bool)
+			if !yield(&user) {
+				return
+			}
+		}
+		MustBeNil(rows.Err())
+	}
+}
+
 func (d *SqlDb) FindArticles(querySql string) []Article {
 	rows, err := d.db.Query(querySql)
 	MustBeNil(err)
@@ -154,7 +344,139 @@ func (d *SqlDb) FindUsersArticlesComments(querySql string, params []any) ([]User
 	return users, articles, comments
 }
 
+func (d *SqlDb) ExecParams(execSql string, params [][]any) {
+	MustBeNil(d.TryExecParams(execSql, params))
+}
+
+func (d *SqlDb) TryExecParams(execSql string, params [][]any) error {
+	var stmt *sql.Stmt
+	var err error
+	if d.tx != nil {
+		stmt, err = d.tx.Prepare(execSql)
+	} else {
+		stmt, err = d.db.Prepare(execSql)
+	}
+	if err != nil {
+		return err
+	}
+	for _, p := range params {
+		if _, err = stmt.Exec(p...); err != nil {
+			stmt.Close()
+			return err
+		}
+	}
+	return stmt.Close()
+}
+
+func (d *SqlDb) QueryStrings(querySql string, params []any) []string {
+	var rows *sql.Rows
+	var err error
+	if d.tx != nil {
+		rows, err = d.tx.Query(querySql, params...)
+	} else {
+		rows, err = d.db.Query(querySql, params...)
+	}
+	MustBeNil(err)
+	var value sql.NullString
+	var values []string
+	for rows.Next() {
+		err = rows.Scan(&value)
+		MustBeNil(err)
+		values = append(values, value.String)
+	}
+	MustBeNil(rows.Err())
+	return values
+}
+
+func (d *SqlDb) FindUsersContext(ctx context.Context, querySql string) ([]User, error) {
+	rows, err := d.db.QueryContext(ctx, querySql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var id sql.NullInt32
+	var created sql.NullInt64
+	var email sql.NullString
+	var active sql.NullBool
+	var users []User
+	for rows.Next() {
+		err = rows.Scan(&id, &created, &email, &active)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, NewUser(int(id.Int32), UnbindTime(created.Int64), email.String, active.Bool))
+	}
+	return users, rows.Err()
+}
+
+func (d *SqlDb) QueryStringsContext(ctx context.Context, querySql string, params []any) ([]string, error) {
+	rows, err := d.db.QueryContext(ctx, querySql, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var value sql.NullString
+	var values []string
+	for rows.Next() {
+		err = rows.Scan(&value)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value.String)
+	}
+	return values, rows.Err()
+}
+
 func (d *SqlDb) Close() {
 	err := d.db.Close()
 	MustBeNil(err)
 }
+
+// SqlAdapter is a Db of a database/sql adapter, one that embeds *SqlDb.
+type SqlAdapter interface {
+	PoolDb
+	DB() *sql.DB
+	Tx() *sql.Tx
+}
+
+// SqlLayer is the base of layers on top of the database/sql adapters, e.g.
+// sqlx: it runs everything through the SqlAdapter, and has the FuncDb,
+// BackupDb and BlobDb methods, which report false if the SqlAdapter has
+// not.
+type SqlLayer struct {
+	SqlAdapter
+}
+
+var _ FuncDb = SqlLayer{}
+var _ BackupDb = SqlLayer{}
+var _ BlobDb = SqlLayer{}
+
+func (d SqlLayer) CreateScalarFunc(name string, fn func(x int64) int64) bool {
+	fdb, ok := d.SqlAdapter.(FuncDb)
+	return ok && fdb.CreateScalarFunc(name, fn)
+}
+
+func (d SqlLayer) CreateAggregateFunc(name string, newAggregate func() Aggregate) bool {
+	fdb, ok := d.SqlAdapter.(FuncDb)
+	return ok && fdb.CreateAggregateFunc(name, newAggregate)
+}
+
+func (d SqlLayer) CreateCollation(name string, cmp func(a, b string) int) bool {
+	fdb, ok := d.SqlAdapter.(FuncDb)
+	return ok && fdb.CreateCollation(name, cmp)
+}
+
+func (d SqlLayer) Backup(dstfile string, pagesPerStep int) bool {
+	bdb, ok := d.SqlAdapter.(BackupDb)
+	return ok && bdb.Backup(dstfile, pagesPerStep)
+}
+
+func (d SqlLayer) WriteBlob(table, column string, rowid int64, src io.Reader, chunkSize int) bool {
+	bdb, ok := d.SqlAdapter.(BlobDb)
+	return ok && bdb.WriteBlob(table, column, rowid, src, chunkSize)
+}
+
+func (d SqlLayer) ReadBlob(table, column string, rowid int64, dst io.Writer, chunkSize int) bool {
+	bdb, ok := d.SqlAdapter.(BlobDb)
+	return ok && bdb.ReadBlob(table, column, rowid, dst, chunkSize)
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// checksum adds a user to a running checksum.
+func checksum(sum int64, u *User) int64 {
+	sum += int64(u.Id) + BindTime(u.Created) + int64(len(u.Email))
+	if u.Active {
+		sum++
+	}
+	return sum
+}
+
+// Insert 1 million user rows in one database transaction.
+// Then stream all users once and compute a checksum, without collecting them.
+// This benchmark is the streaming variant of benchSimple.
+func benchStreamSimple(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	db := makeDb(dbfile)
+	defer db.Close()
+	initSchema(db)
+	// insert users
+	var users []User
+	g := newGenerator()
+	created := g.Clock(time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local), time.Minute)
+	nusers := scaled(1_000_000)
+	var want int64
+	for i := range nusers {
+		users = append(users, NewUser(
+			i+1,                                  // id,
+			created(),                            // created,
+			g.Email("user%08d@example.com", i+1), // email,
+			true,                                 // active,
+		))
+		want = checksum(want, &users[i])
+	}
+	db.Begin()
+	db.InsertUsers(insertUserSql, users)
+	db.Commit()
+	users = nil
+	// stream users
+	stopProfiles := startProfiles("stream.simple.query", db.DriverName())
+	var have int64
+	var n int
+	query := t.measure("stream.simple.query", func() {
+		have, n = 0, 0
+		for u := range db.StreamUsers("SELECT id,created,email,active FROM users ORDER BY id") {
+			n++
+			MustBeEqual(n, u.Id)
+			have = checksum(have, u)
+		}
+	}, nil)
+	stopProfiles()
+	if verbose {
+		log.Printf("  query took %d ms", query.millis())
+	}
+	// validate query result
+	MustBeEqual(nusers, n)
+	MustBeEqual(want, have)
+	// print results
+	bench := "9_stream/simple"
+	logResult(bench, "query", db.DriverName(), query)
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
+
+// Insert 10000 users with N bytes of row content.
+// Then stream all users once and compute a checksum, without collecting them.
+// This benchmark is the streaming variant of benchLarge.
+func benchStreamLarge(t *timing, dbfile string, nsize int, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	db := makeDb(dbfile)
+	defer db.Close()
+	initSchema(db)
+	// insert user with large emails
+	g := newGenerator()
+	created := g.Clock(time.Date(2023, 10, 1, 10, 0, 0, 0, time.Local), time.Second)
+	nusers := scaled(10_000)
+	email := strings.Repeat("a", nsize)
+	var users []User
+	var want int64
+	for i := range nusers {
+		users = append(users, NewUser(
+			i+1,           // Id
+			created(),     // Created
+			g.Text(email), // Email
+			true,          // Active
+		))
+		want = checksum(want, &users[i])
+	}
+	db.Begin()
+	db.InsertUsers(insertUserSql, users)
+	db.Commit()
+	users = nil
+	// stream users
+	stopProfiles := startProfiles(fmt.Sprintf("stream.large.%06d.query", nsize), db.DriverName())
+	var have int64
+	var n int
+	query := t.measure(fmt.Sprintf("stream.large.%06d.query", nsize), func() {
+		have, n = 0, 0
+		for u := range db.StreamUsers("SELECT id,created,email,active FROM users ORDER BY id") {
+			n++
+			MustBeEqual(n, u.Id)
+			have = checksum(have, u)
+		}
+	}, nil)
+	stopProfiles()
+	if verbose {
+		log.Printf("  query took %d ms", query.millis())
+	}
+	// validate query result
+	MustBeEqual(nusers, n)
+	MustBeEqual(want, have)
+	// print results
+	bench := fmt.Sprintf("9_stream/large/%06d", nsize)
+	logResult(bench, "query", db.DriverName(), query)
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
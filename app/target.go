@@ -0,0 +1,63 @@
+package app
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+// Database targets, selected with the -target flag.
+const (
+	targetFile   = "file"   // the dbfile given on the command line
+	targetMemory = "memory" // a private in-memory database, one per connection
+	targetShared = "shared" // an in-memory database, shared by all connections of the process
+	targetTmpfs  = "tmpfs"  // the dbfile, moved into a tmpfs directory
+)
+
+const memoryDbfile = ":memory:"
+const sharedDbfile = "file:benchdb?mode=memory&cache=shared"
+
+// privateMemory is true if the target is a private in-memory database.
+// Since every connection sees its own empty database, NewSqlDb limits
+// database/sql pools to one connection then.
+var privateMemory bool
+
+// targetDbfile returns the dbfile that makeDb is called with for a target.
+func targetDbfile(target, dbfile, tmpfsDir string) string {
+	switch target {
+	case targetFile:
+		if dbfile == "" {
+			log.Fatal("dbfile empty, cannot bench")
+		}
+		return dbfile
+	case targetMemory:
+		privateMemory = true
+		return memoryDbfile
+	case targetShared:
+		return sharedDbfile
+	case targetTmpfs:
+		if dbfile == "" {
+			log.Fatal("dbfile empty, cannot bench")
+		}
+		if fstype := filesystemType(tmpfsDir); fstype != "tmpfs" {
+			log.Fatalf("tmpfsdir %q is %s, not tmpfs", tmpfsDir, fstype)
+		}
+		return filepath.Join(tmpfsDir, filepath.Base(dbfile))
+	}
+	log.Fatalf("unknown target %q, want one of %s,%s,%s,%s", target, targetFile, targetMemory, targetShared, targetTmpfs)
+	return ""
+}
+
+// isMemoryDbfile reports whether dbfile denotes an in-memory database,
+// which has no files on disk.
+func isMemoryDbfile(dbfile string) bool {
+	return dbfile == memoryDbfile || strings.Contains(dbfile, "mode=memory")
+}
+
+// hasUsersTable reports whether db sees the users table. A connection
+// opened by a benchmark does not see it if the database is a private
+// in-memory database, or lives in another process.
+func hasUsersTable(db Db) bool {
+	values := db.QueryStrings("SELECT count(*) FROM sqlite_master WHERE type='table' AND name='users'", nil)
+	return len(values) == 1 && values[0] == "1"
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// upsertHits is the ratio of upserts of existing users in the upsert
+// benchmark, set with the -upserthits flag.
+var upsertHits = 0.5
+
+// checkUpsertHits exits if upsertHits is not a ratio.
+func checkUpsertHits() {
+	if upsertHits < 0 || upsertHits > 1 {
+		log.Fatalf("invalid upserthits %v, want 0 to 1", upsertHits)
+	}
+}
+
+// Insert 100000 users in one database transaction.
+// Then upsert 100000 users in one database transaction, a ratio of
+// upsertHits of them existing users, the others new users, with
+// ON CONFLICT DO UPDATE (update), INSERT OR REPLACE (replace),
+// ON CONFLICT DO NOTHING (ignore), and ON CONFLICT DO UPDATE with a
+// RETURNING clause, executed as a query (return).
+// Verify the users afterwards.
+// This benchmark is used to simulate the most common write pattern of
+// many services.
+func benchUpsert(t *timing, dbfile string, makeDb func(dbfile string) Db) {
+	removeDbfiles(dbfile)
+	db := makeDb(dbfile)
+	defer db.Close()
+	initSchema(db)
+	version := sqliteVersion(db)
+	bench := fmt.Sprintf("17_upsert/%d", int(upsertHits*100))
+	if version < 3_024_000 {
+		// upserts are available since SQLite 3.24.0
+		for _, op := range []string{"update", "replace", "ignore", "return"} {
+			logUnsupported(bench, op, db.DriverName())
+		}
+		return
+	}
+	nusers := scaled(100_000)
+	users := makeSimpleUsers(nusers)
+	// load loads the users
+	load := func() {
+		db.Exec("DELETE FROM users")
+		db.Begin()
+		db.InsertUsers(insertUserSql, users)
+		db.Commit()
+	}
+	load()
+	// make upserts, spread the hits evenly: upsert i is a hit if the
+	// number of hits grows by one with it
+	nupserts := scaled(100_000)
+	created := time.Date(2024, 10, 1, 10, 0, 0, 0, time.Local)
+	var upserts []User
+	nhits := 0
+	for i := range nupserts {
+		var u User
+		if int(float64(i+1)*upsertHits) > nhits {
+			u = users[nhits*nusers/max(1, int(float64(nupserts)*upsertHits))]
+			nhits++
+		} else {
+			id := nusers + 1 + i - nhits
+			u = NewUser(id, created.Add(time.Duration(id)*time.Minute), "", true)
+		}
+		u.Email = fmt.Sprintf("upsert%08d@example.com", u.Id)
+		u.Active = false
+		upserts = append(upserts, u)
+	}
+	params := make([][]any, 0, nupserts)
+	for _, u := range upserts {
+		params = append(params, []any{u.Id, BindTime(u.Created), u.Email, u.Active})
+	}
+	// validate checks the users after the upserts, hits are changed
+	// unless ignored
+	validate := func(ignored bool) {
+		want := make(map[int]User, nusers+nupserts)
+		for _, u := range users {
+			want[u.Id] = u
+		}
+		for _, u := range upserts {
+			if _, found := want[u.Id]; !found || !ignored {
+				want[u.Id] = u
+			}
+		}
+		values := db.FindUsers("SELECT id,created,email,active FROM users ORDER BY id")
+		MustBeEqual(len(want), len(values))
+		for _, u := range values {
+			MustBeEqual(want[u.Id], u)
+		}
+	}
+	// upsert runs one statement, then validates and reloads the users
+	upsert := func(op string, ignored bool, exec func()) measurement {
+		stopProfiles := startProfiles("upsert."+op, db.DriverName())
+		m := t.measure("upsert."+op, func() {
+			db.Begin()
+			exec()
+			db.Commit()
+		}, load)
+		stopProfiles()
+		validate(ignored)
+		load()
+		return m
+	}
+	const conflictSql = "INSERT INTO users(id,created,email,active) VALUES(?,?,?,?)" +
+		" ON CONFLICT(id) DO UPDATE SET email=excluded.email, active=excluded.active"
+	update := upsert("update", false, func() {
+		db.ExecParams(conflictSql, params)
+	})
+	replace := upsert("replace", false, func() {
+		db.ExecParams("INSERT OR REPLACE INTO users(id,created,email,active) VALUES(?,?,?,?)", params)
+	})
+	ignore := upsert("ignore", true, func() {
+		db.ExecParams("INSERT INTO users(id,created,email,active) VALUES(?,?,?,?) ON CONFLICT(id) DO NOTHING", params)
+	})
+	// RETURNING is available since SQLite 3.35.0
+	var ret measurement
+	retOk := version >= 3_035_000
+	if retOk {
+		ret = upsert("return", false, func() {
+			for i, p := range params {
+				values := db.QueryStrings(conflictSql+" RETURNING id", p)
+				MustBeEqual(1, len(values))
+				MustBeEqual(strconv.Itoa(upserts[i].Id), values[0])
+			}
+		})
+	}
+	if verbose {
+		log.Printf("  upserted %d users, %d hits", nupserts, nhits)
+	}
+	// print results
+	logResult(bench, "update", db.DriverName(), update)
+	logResult(bench, "replace", db.DriverName(), replace)
+	logResult(bench, "ignore", db.DriverName(), ignore)
+	if retOk {
+		logResult(bench, "return", db.DriverName(), ret)
+	} else {
+		logUnsupported(bench, "return", db.DriverName())
+	}
+	logValue(bench, "dbsize", db.DriverName(), dbsize(dbfile))
+	logText(bench, "pragma", db.DriverName(), effectivePragmas(db))
+}
@@ -2,7 +2,11 @@ package app
 
 import (
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 )
 
@@ -36,23 +40,35 @@ func MustBeSet(a any) {
 	}
 }
 
-func removeDbfiles(dbfile string) {
-	// remove db file and temp files
-	names := []string{dbfile, dbfile + "-shm", dbfile + "-wal", dbfile + "-journal"}
-	for _, name := range names {
-		os.Remove(name)
-		_, err := os.Stat(name) // file must really be gone
-		MustBeSet(err)
+func removeDbfiles(dbfiles ...string) {
+	for _, dbfile := range dbfiles {
+		if isMemoryDbfile(dbfile) {
+			continue
+		}
+		// remove db file and temp files, and super-journals left by
+		// transactions across attached databases
+		names := []string{dbfile, dbfile + "-shm", dbfile + "-wal", dbfile + "-journal"}
+		superJournals, err := filepath.Glob(dbfile + "-mj*")
+		MustBeNil(err)
+		names = append(names, superJournals...)
+		for _, name := range names {
+			os.Remove(name)
+			_, err := os.Stat(name) // file must really be gone
+			MustBeSet(err)
+		}
 	}
 }
 
-func dbsize(dbfile string) int64 {
+// dbsize returns the total size of the database files, or 0 for in-memory databases.
+func dbsize(dbfiles ...string) int64 {
 	var total int64
-	names := []string{dbfile, dbfile + "-shm", dbfile + "-wal", dbfile + "-journal"}
-	for _, name := range names {
-		fi, err := os.Stat(name)
-		if err == nil {
-			total += fi.Size()
+	for _, dbfile := range dbfiles {
+		names := []string{dbfile, dbfile + "-shm", dbfile + "-wal", dbfile + "-journal"}
+		for _, name := range names {
+			fi, err := os.Stat(name)
+			if err == nil {
+				total += fi.Size()
+			}
 		}
 	}
 	return total
@@ -61,3 +77,52 @@ func dbsize(dbfile string) int64 {
 func millisSince(t time.Time) int64 {
 	return time.Since(t).Milliseconds()
 }
+
+// sqliteVersion returns the SQLite library version of db as a number,
+// e.g. 3046000 for "3.46.0", like the SQLITE_VERSION_NUMBER macro does.
+func sqliteVersion(db Db) int {
+	values := db.QueryStrings("SELECT sqlite_version()", nil)
+	MustBeEqual(1, len(values))
+	var major, minor, patch int
+	_, err := fmt.Sscanf(values[0], "%d.%d.%d", &major, &minor, &patch)
+	MustBeNil(err)
+	return major*1_000_000 + minor*1_000 + patch
+}
+
+// resultLine formats the start of a result line, e.g.
+// "1_simple - insert - mattn     ", like the result lines of all benchmarks.
+// Longer driver names, e.g. "modernc+sqlx", overflow the driver column.
+func resultLine(bench, op, driverName string) string {
+	return fmt.Sprintf("%s - %-6s - %-10s", bench, op, driverName)
+}
+
+// logValue prints a result line with a number, e.g. the dbsize.
+func logValue[T ~int | ~int64](bench, op, driverName string, value T) {
+	log.Printf("%s - %10d", resultLine(bench, op, driverName), value)
+}
+
+// logText prints a result line with a text, e.g. the pragmas.
+func logText(bench, op, driverName string, text string) {
+	log.Printf("%s - %s", resultLine(bench, op, driverName), text)
+}
+
+func logUnsupported(bench, op, driverName string) {
+	log.Printf("%s - %10s", resultLine(bench, op, driverName), "unsupported")
+}
+
+// CopyChunks copies src to dst with a buffer of chunkSize bytes, like
+// io.CopyBuffer, but without the shortcuts of io.WriterTo and
+// io.ReaderFrom, so that each chunk is one Read and one Write call.
+func CopyChunks(dst io.Writer, src io.Reader, chunkSize int) error {
+	_, err := io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, make([]byte, chunkSize))
+	return err
+}
+
+// queryInt64 returns the result of a query for a single integer.
+func queryInt64(db Db, querySql string) int64 {
+	values := db.QueryStrings(querySql, nil)
+	MustBeEqual(1, len(values))
+	n, err := strconv.ParseInt(values[0], 10, 64)
+	MustBeNil(err)
+	return n
+}
@@ -0,0 +1,204 @@
+package app
+
+import (
+	"context"
+	"io"
+	"iter"
+	"sync"
+)
+
+// verifyMode runs all benchmarks at tiny scale, with checks for concurrent
+// use of Db instances. The results are meaningless, build the bench
+// binaries with -race to catch data races in benchmarks and adapters.
+var verifyMode = false
+
+// allBenchmarks are the names of all benchmarks, for verify mode.
+const allBenchmarks = "simple,real,complex,many,large,concurrent,json,funcs,stream,cancel,durable,pool,backup,migrate,bulk,upsert,fkeys,blob,attach,paginate,procs"
+
+// scaled returns n, or n/1000 (but at least 1) in verify mode.
+func scaled(n int) int {
+	if !verifyMode {
+		return n
+	}
+	return max(1, n/1000)
+}
+
+// verifyMakeDb wraps makeDb, so that every Db it makes panics when it is
+// used by two goroutines at the same time. Most adapters open their
+// connections with NOMUTEX (or do not serialize calls otherwise), they may
+// be handed from one goroutine to another, but must never be used
+// concurrently. A PoolDb may run queries concurrently, everything else
+// must not overlap with any other call.
+func verifyMakeDb(makeDb func(dbfile string) Db) func(dbfile string) Db {
+	return func(dbfile string) Db {
+		db := makeDb(dbfile)
+		if pdb, ok := db.(PoolDb); ok {
+			return &checkedPoolDb{checkedDb{Db: db, shared: true}, pdb}
+		}
+		return &checkedDb{Db: db}
+	}
+}
+
+// checkedDb is a Db that panics on concurrent use, see verifyMakeDb.
+type checkedDb struct {
+	Db
+	shared     bool // queries may run concurrently
+	mu         sync.Mutex
+	nexclusive int // calls that must not overlap with any other call
+	nshared    int // queries, if shared
+}
+
+var _ FuncDb = (*checkedDb)(nil)
+var _ BackupDb = (*checkedDb)(nil)
+var _ BlobDb = (*checkedDb)(nil)
+
+// enter marks the start of a call and returns the function that marks its end.
+func (d *checkedDb) enter(method string, query bool) func() {
+	exclusive := !query || !d.shared
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	Must(d.nexclusive == 0 && (!exclusive || d.nshared == 0), "concurrent use of %s Db in %s", d.Db.DriverName(), method)
+	if exclusive {
+		d.nexclusive++
+	} else {
+		d.nshared++
+	}
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if exclusive {
+			d.nexclusive--
+		} else {
+			d.nshared--
+		}
+	}
+}
+
+func (d *checkedDb) Exec(sqls ...string) {
+	defer d.enter("Exec", false)()
+	d.Db.Exec(sqls...)
+}
+
+func (d *checkedDb) Begin() {
+	defer d.enter("Begin", false)()
+	d.Db.Begin()
+}
+
+func (d *checkedDb) Commit() {
+	defer d.enter("Commit", false)()
+	d.Db.Commit()
+}
+
+func (d *checkedDb) InsertUsers(insertSql string, users []User) {
+	defer d.enter("InsertUsers", false)()
+	d.Db.InsertUsers(insertSql, users)
+}
+
+func (d *checkedDb) InsertArticles(insertSql string, articles []Article) {
+	defer d.enter("InsertArticles", false)()
+	d.Db.InsertArticles(insertSql, articles)
+}
+
+func (d *checkedDb) InsertComments(insertSql string, comments []Comment) {
+	defer d.enter("InsertComments", false)()
+	d.Db.InsertComments(insertSql, comments)
+}
+
+func (d *checkedDb) FindUsers(querySql string) []User {
+	defer d.enter("FindUsers", true)()
+	return d.Db.FindUsers(querySql)
+}
+
+func (d *checkedDb) StreamUsers(querySql string) iter.Seq[*User] {
+	return func(yield func(*User) bool) {
+		defer d.enter("StreamUsers", true)()
+		for u := range d.Db.StreamUsers(querySql) {
+			if !yield(u) {
+				return
+			}
+		}
+	}
+}
+
+func (d *checkedDb) FindUsersArticlesComments(querySql string, params []any) ([]User, []Article, []Comment) {
+	defer d.enter("FindUsersArticlesComments", true)()
+	return d.Db.FindUsersArticlesComments(querySql, params)
+}
+
+func (d *checkedDb) ExecParams(execSql string, params [][]any) {
+	defer d.enter("ExecParams", false)()
+	d.Db.ExecParams(execSql, params)
+}
+
+func (d *checkedDb) TryExecParams(execSql string, params [][]any) error {
+	defer d.enter("TryExecParams", false)()
+	return d.Db.TryExecParams(execSql, params)
+}
+
+func (d *checkedDb) QueryStrings(querySql string, params []any) []string {
+	defer d.enter("QueryStrings", true)()
+	return d.Db.QueryStrings(querySql, params)
+}
+
+func (d *checkedDb) FindUsersContext(ctx context.Context, querySql string) ([]User, error) {
+	defer d.enter("FindUsersContext", true)()
+	return d.Db.FindUsersContext(ctx, querySql)
+}
+
+func (d *checkedDb) QueryStringsContext(ctx context.Context, querySql string, params []any) ([]string, error) {
+	defer d.enter("QueryStringsContext", true)()
+	return d.Db.QueryStringsContext(ctx, querySql, params)
+}
+
+func (d *checkedDb) Close() {
+	defer d.enter("Close", false)()
+	d.Db.Close()
+}
+
+func (d *checkedDb) CreateScalarFunc(name string, fn func(x int64) int64) bool {
+	defer d.enter("CreateScalarFunc", false)()
+	fdb, ok := d.Db.(FuncDb)
+	return ok && fdb.CreateScalarFunc(name, fn)
+}
+
+func (d *checkedDb) CreateAggregateFunc(name string, newAggregate func() Aggregate) bool {
+	defer d.enter("CreateAggregateFunc", false)()
+	fdb, ok := d.Db.(FuncDb)
+	return ok && fdb.CreateAggregateFunc(name, newAggregate)
+}
+
+func (d *checkedDb) CreateCollation(name string, cmp func(a, b string) int) bool {
+	defer d.enter("CreateCollation", false)()
+	fdb, ok := d.Db.(FuncDb)
+	return ok && fdb.CreateCollation(name, cmp)
+}
+
+func (d *checkedDb) Backup(dstfile string, pagesPerStep int) bool {
+	defer d.enter("Backup", false)()
+	bdb, ok := d.Db.(BackupDb)
+	return ok && bdb.Backup(dstfile, pagesPerStep)
+}
+
+func (d *checkedDb) WriteBlob(table, column string, rowid int64, src io.Reader, chunkSize int) bool {
+	defer d.enter("WriteBlob", false)()
+	bdb, ok := d.Db.(BlobDb)
+	return ok && bdb.WriteBlob(table, column, rowid, src, chunkSize)
+}
+
+func (d *checkedDb) ReadBlob(table, column string, rowid int64, dst io.Writer, chunkSize int) bool {
+	defer d.enter("ReadBlob", true)()
+	bdb, ok := d.Db.(BlobDb)
+	return ok && bdb.ReadBlob(table, column, rowid, dst, chunkSize)
+}
+
+// checkedPoolDb is a checkedDb for a PoolDb.
+type checkedPoolDb struct {
+	checkedDb
+	pdb PoolDb
+}
+
+var _ PoolDb = (*checkedPoolDb)(nil)
+
+func (d *checkedPoolDb) PoolSize() int {
+	return d.pdb.PoolSize()
+}
@@ -16,7 +16,11 @@ echo build craw     && go build -o bin/ ./cmd/bench-craw
 echo build eaton    && go build -o bin/ ./cmd/bench-eaton
 echo build glebarez && go build -o bin/ ./cmd/bench-glebarez
 echo build mattn    && go build -o bin/ ./cmd/bench-mattn
+echo build mattn-sqlx && go build -o bin/ ./cmd/bench-mattn-sqlx
+echo build mattn-sqlc && go build -o bin/ ./cmd/bench-mattn-sqlc
 echo build modernc  && go build -o bin/ ./cmd/bench-modernc
+echo build modernc-sqlx && go build -o bin/ ./cmd/bench-modernc-sqlx
+echo build modernc-sqlc && go build -o bin/ ./cmd/bench-modernc-sqlc
 echo build ncruces  && go build -o bin/ ./cmd/bench-ncruces
 echo build sqinn    && go build -o bin/ ./cmd/bench-sqinn
 echo build zombie   && go build -o bin/ ./cmd/bench-zombie
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/bvinc"
+)
+
+func main() {
+	app.Run(bvinc.New)
+}
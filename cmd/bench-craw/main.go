@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/craw"
+)
+
+func main() {
+	app.Run(craw.New)
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/mattn"
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/sqlc"
+)
+
+func main() {
+	app.Run(sqlc.Wrap(mattn.New))
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/modernc"
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/sqlx"
+)
+
+func main() {
+	app.Run(sqlx.Wrap(modernc.New))
+}
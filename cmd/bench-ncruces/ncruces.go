@@ -1,16 +0,0 @@
-package main
-
-import (
-	"database/sql"
-
-	"github.com/cvilsmeier/go-sqlite-bench/app"
-	_ "github.com/ncruces/go-sqlite3/driver"
-)
-
-func main() {
-	app.Run(func(dbfile string) app.Db {
-		db, err := sql.Open("sqlite3", dbfile)
-		app.MustBeNil(err)
-		return app.NewSqlDb("ncruces", db)
-	})
-}
@@ -1,24 +1,24 @@
-package main
+// Package bvinc is the bvinc adapter of the benchmarks.
+package bvinc
 
 import (
+	"context"
+	"io"
+	"iter"
+
 	"github.com/bvinc/go-sqlite-lite/sqlite3"
 	"github.com/cvilsmeier/go-sqlite-bench/app"
 )
 
-func main() {
-	app.Run(func(dbfile string) app.Db {
-		return newDb(dbfile)
-	})
-}
-
 type dbImpl struct {
 	conn *sqlite3.Conn
 }
 
-var _ app.Db = (*dbImpl)(nil)
+var _ app.BackupDb = (*dbImpl)(nil)
 
-func newDb(dbfile string) app.Db {
-	conn, err := sqlite3.Open(dbfile, sqlite3.OPEN_READWRITE|sqlite3.OPEN_CREATE|sqlite3.OPEN_NOMUTEX)
+// New opens a Db for dbfile.
+func New(dbfile string) app.Db {
+	conn, err := sqlite3.Open(dbfile, sqlite3.OPEN_READWRITE|sqlite3.OPEN_CREATE|sqlite3.OPEN_URI|sqlite3.OPEN_NOMUTEX)
 	app.MustBeNil(err)
 	return &dbImpl{conn}
 }
@@ -116,6 +116,37 @@ func (d *dbImpl) FindUsers(querySql string) []app.User {
 	return users
 }
 
+func (d *dbImpl) StreamUsers(querySql string) iter.Seq[*app.User] {
+	return func(yield func(*app.User) bool) {
+		stmt, err := d.conn.Prepare(querySql)
+		app.MustBeNil(err)
+		defer stmt.Close()
+		more, err := stmt.Step()
+		app.MustBeNil(err)
+		var user app.User
+		for more {
+			id, ok, err := stmt.ColumnInt(0)
+			app.MustBe(ok)
+			app.MustBeNil(err)
+			created, ok, err := stmt.ColumnInt64(1)
+			app.MustBe(ok)
+			app.MustBeNil(err)
+			email, ok, err := stmt.ColumnText(2)
+			app.MustBe(ok)
+			app.MustBeNil(err)
+			active, ok, err := stmt.ColumnInt(3)
+			app.MustBe(ok)
+			app.MustBeNil(err)
+			user = app.NewUser(id, app.UnbindTime(created), email, active != 0)
+			if !yield(&user) {
+				return
+			}
+			more, err = stmt.Step()
+			app.MustBeNil(err)
+		}
+	}
+}
+
 func (d *dbImpl) FindArticles(querySql string) []app.Article {
 	stmt, err := d.conn.Prepare(querySql)
 	app.MustBeNil(err)
@@ -230,7 +261,155 @@ func (d *dbImpl) FindUsersArticlesComments(querySql string, params []any) ([]app
 	return users, articles, comments
 }
 
+func (d *dbImpl) ExecParams(execSql string, params [][]any) {
+	app.MustBeNil(d.TryExecParams(execSql, params))
+}
+
+func (d *dbImpl) TryExecParams(execSql string, params [][]any) error {
+	stmt, err := d.conn.Prepare(execSql)
+	if err != nil {
+		return err
+	}
+	for _, p := range params {
+		err = stmt.Bind(p...)
+		if err == nil {
+			_, err = stmt.Step()
+		}
+		if err == nil {
+			err = stmt.Reset()
+		}
+		if err != nil {
+			stmt.Close()
+			return err
+		}
+	}
+	return stmt.Close()
+}
+
+func (d *dbImpl) QueryStrings(querySql string, params []any) []string {
+	stmt, err := d.conn.Prepare(querySql)
+	app.MustBeNil(err)
+	if len(params) > 0 {
+		err = stmt.Bind(params...)
+		app.MustBeNil(err)
+	}
+	more, err := stmt.Step()
+	app.MustBeNil(err)
+	var values []string
+	for more {
+		value, _, err := stmt.ColumnText(0)
+		app.MustBeNil(err)
+		values = append(values, value)
+		more, err = stmt.Step()
+		app.MustBeNil(err)
+	}
+	err = stmt.Close()
+	app.MustBeNil(err)
+	return values
+}
+
+func (d *dbImpl) FindUsersContext(ctx context.Context, querySql string) ([]app.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer d.interruptWhenDone(ctx)()
+	stmt, err := d.conn.Prepare(querySql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	var users []app.User
+	for {
+		more, err := stmt.Step()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			break
+		}
+		var user app.User
+		var created int64
+		err = stmt.Scan(&user.Id, &created, &user.Email, &user.Active)
+		if err != nil {
+			return nil, err
+		}
+		user.Created = app.UnbindTime(created)
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (d *dbImpl) QueryStringsContext(ctx context.Context, querySql string, params []any) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer d.interruptWhenDone(ctx)()
+	stmt, err := d.conn.Prepare(querySql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	if len(params) > 0 {
+		err = stmt.Bind(params...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var values []string
+	for {
+		more, err := stmt.Step()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			break
+		}
+		value, _, err := stmt.ColumnText(0)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// interruptWhenDone interrupts the connection when ctx is done.
+// The returned func must be called when the database operation has ended,
+// it waits for a concurrent interrupt to complete.
+func (d *dbImpl) interruptWhenDone(ctx context.Context) func() {
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		d.conn.Interrupt()
+		close(interrupted)
+	})
+	return func() {
+		if !stop() {
+			<-interrupted
+		}
+	}
+}
+
 func (d *dbImpl) Close() {
 	err := d.conn.Close()
 	app.MustBeNil(err)
 }
+
+func (d *dbImpl) Backup(dstfile string, pagesPerStep int) bool {
+	dst, err := sqlite3.Open(dstfile, sqlite3.OPEN_READWRITE|sqlite3.OPEN_CREATE|sqlite3.OPEN_URI|sqlite3.OPEN_NOMUTEX)
+	app.MustBeNil(err)
+	defer func() { app.MustBeNil(dst.Close()) }()
+	b, err := d.conn.Backup("main", dst, "main")
+	app.MustBeNil(err)
+	for {
+		err = b.Step(pagesPerStep)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			b.Close()
+			app.MustBeNil(err)
+		}
+	}
+	app.MustBeNil(b.Close())
+	return true
+}
@@ -0,0 +1,516 @@
+// Package craw is the craw adapter of the benchmarks.
+package craw
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+
+	"crawshaw.io/sqlite"
+	"crawshaw.io/sqlite/sqlitex"
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+)
+
+type dbImpl struct {
+	pool   *sqlitex.Pool
+	size   int
+	txConn *sqlite.Conn // or nil if no tx active right now
+}
+
+var _ app.FuncDb = (*dbImpl)(nil)
+var _ app.BackupDb = (*dbImpl)(nil)
+var _ app.BlobDb = (*dbImpl)(nil)
+
+// poolDbImpl is a dbImpl with a pool of app.PoolSize connections.
+type poolDbImpl struct {
+	*dbImpl
+}
+
+var _ app.PoolDb = (*poolDbImpl)(nil)
+
+// New opens a Db for dbfile, with one connection.
+func New(dbfile string) app.Db {
+	return open(dbfile, 1)
+}
+
+// NewPool opens a Db for dbfile, with a pool of app.PoolSize connections.
+func NewPool(dbfile string) app.Db {
+	return &poolDbImpl{open(dbfile, app.PoolSize())}
+}
+
+func open(dbfile string, size int) *dbImpl {
+	flags := sqlite.SQLITE_OPEN_READWRITE |
+		sqlite.SQLITE_OPEN_CREATE |
+		sqlite.SQLITE_OPEN_URI |
+		sqlite.SQLITE_OPEN_NOMUTEX
+	if dbfile == ":memory:" {
+		dbfile = "file::memory:?mode=memory" // sqlitex rejects ":memory:" for pools
+	}
+	pool, err := sqlitex.Open(dbfile, flags, size)
+	app.MustBeNil(err)
+	return &dbImpl{pool: pool, size: size}
+}
+
+func (d *dbImpl) DriverName() string {
+	return "craw"
+}
+
+func (d *poolDbImpl) DriverName() string {
+	return "craw-pool"
+}
+
+func (d *poolDbImpl) PoolSize() int {
+	return d.size
+}
+
+func (d *dbImpl) Exec(sqls ...string) {
+	for _, s := range sqls {
+		if d.txConn == nil && isPragma(s) {
+			d.all(func(conn *sqlite.Conn) { d.exec(conn, s) }) // pragmas are per connection
+			continue
+		}
+		conn := d.get()
+		d.exec(conn, s)
+		d.put(conn)
+	}
+}
+
+func (d *dbImpl) Begin() {
+	conn := d.get()
+	d.exec(conn, "BEGIN")
+	d.txConn = conn // all statements use conn until Commit
+}
+
+func (d *dbImpl) Commit() {
+	conn := d.txConn
+	d.exec(conn, "COMMIT")
+	d.txConn = nil
+	d.put(conn)
+}
+
+func (d *dbImpl) InsertUsers(insertSql string, users []app.User) {
+	conn := d.get()
+	defer d.put(conn)
+	stmt := conn.Prep(insertSql)
+	for _, u := range users {
+		//	Id        int
+		//	Created   time.Time
+		//	Email     string
+		//	Active    bool
+		stmt.BindInt64(1, int64(u.Id))
+		stmt.BindInt64(2, app.BindTime(u.Created))
+		stmt.BindText(3, u.Email)
+		stmt.BindBool(4, u.Active)
+		_, err := stmt.Step()
+		app.MustBeNil(err)
+		err = stmt.Reset()
+		app.MustBeNil(err)
+	}
+	err := stmt.Finalize()
+	app.MustBeNil(err)
+}
+
+func (d *dbImpl) InsertArticles(insertSql string, articles []app.Article) {
+	conn := d.get()
+	defer d.put(conn)
+	stmt := conn.Prep(insertSql)
+	for _, u := range articles {
+		stmt.BindInt64(1, int64(u.Id))
+		stmt.BindInt64(2, app.BindTime(u.Created))
+		stmt.BindInt64(3, int64(u.UserId))
+		stmt.BindText(4, u.Text)
+		_, err := stmt.Step()
+		app.MustBeNil(err)
+		err = stmt.Reset()
+		app.MustBeNil(err)
+	}
+	err := stmt.Finalize()
+	app.MustBeNil(err)
+}
+
+func (d *dbImpl) InsertComments(insertSql string, comments []app.Comment) {
+	conn := d.get()
+	defer d.put(conn)
+	stmt := conn.Prep(insertSql)
+	for _, u := range comments {
+		stmt.BindInt64(1, int64(u.Id))
+		stmt.BindInt64(2, app.BindTime(u.Created))
+		stmt.BindInt64(3, int64(u.ArticleId))
+		stmt.BindText(4, u.Text)
+		_, err := stmt.Step()
+		app.MustBeNil(err)
+		err = stmt.Reset()
+		app.MustBeNil(err)
+	}
+	err := stmt.Finalize()
+	app.MustBeNil(err)
+}
+
+func (d *dbImpl) FindUsers(querySql string) []app.User {
+	conn := d.get()
+	defer d.put(conn)
+	stmt, err := conn.Prepare(querySql)
+	app.MustBeNil(err)
+	more, err := stmt.Step()
+	app.MustBeNil(err)
+	var users []app.User
+	for more {
+		user := app.NewUser(
+			stmt.ColumnInt(0),                   // id,
+			app.UnbindTime(stmt.ColumnInt64(1)), // created,
+			stmt.ColumnText(2),                  // email,
+			stmt.ColumnInt(3) != 0,              // active,
+		)
+		users = append(users, user)
+		more, err = stmt.Step()
+		app.MustBeNil(err)
+	}
+	return users
+}
+
+func (d *dbImpl) StreamUsers(querySql string) iter.Seq[*app.User] {
+	return func(yield func(*app.User) bool) {
+		conn := d.get()
+		defer d.put(conn)
+		stmt, err := conn.Prepare(querySql)
+		app.MustBeNil(err)
+		defer stmt.Finalize()
+		more, err := stmt.Step()
+		app.MustBeNil(err)
+		var user app.User
+		for more {
+			user = app.NewUser(
+				stmt.ColumnInt(0),                   // id,
+				app.UnbindTime(stmt.ColumnInt64(1)), // created,
+				stmt.ColumnText(2),                  // email,
+				stmt.ColumnInt(3) != 0,              // active,
+			)
+			if !yield(&user) {
+				return
+			}
+			more, err = stmt.Step()
+			app.MustBeNil(err)
+		}
+	}
+}
+
+func (d *dbImpl) FindArticles(querySql string) []app.Article {
+	conn := d.get()
+	defer d.put(conn)
+	stmt, err := conn.Prepare(querySql)
+	app.MustBeNil(err)
+	more, err := stmt.Step()
+	app.MustBeNil(err)
+	var articles []app.Article
+	for more {
+		article := app.NewArticle(
+			stmt.ColumnInt(0),                   // id,
+			app.UnbindTime(stmt.ColumnInt64(1)), // created,
+			stmt.ColumnInt(2),                   // userId,
+			stmt.ColumnText(3),                  // text,
+		)
+		articles = append(articles, article)
+		more, err = stmt.Step()
+		app.MustBeNil(err)
+	}
+	return articles
+}
+
+func (d *dbImpl) FindUsersArticlesComments(querySql string, params []any) ([]app.User, []app.Article, []app.Comment) {
+	conn := d.get()
+	defer d.put(conn)
+	stmt, err := conn.Prepare(querySql)
+	app.MustBeNil(err)
+	bind(stmt, params)
+	more, err := stmt.Step()
+	app.MustBeNil(err)
+	// collections
+	var users []app.User
+	userIndexer := make(map[int]int)
+	var articles []app.Article
+	articleIndexer := make(map[int]int)
+	var comments []app.Comment
+	commentIndexer := make(map[int]int)
+	for more {
+		user := app.NewUser(
+			stmt.ColumnInt(0),                   // id,
+			app.UnbindTime(stmt.ColumnInt64(1)), // created,
+			stmt.ColumnText(2),                  // email,
+			stmt.ColumnInt(3) != 0,              // active,
+		)
+		article := app.NewArticle(
+			stmt.ColumnInt(4),                   // id,
+			app.UnbindTime(stmt.ColumnInt64(5)), // created,
+			stmt.ColumnInt(6),                   // userId,
+			stmt.ColumnText(7),                  // text,
+		)
+		comment := app.NewComment(
+			stmt.ColumnInt(8),                   // id,
+			app.UnbindTime(stmt.ColumnInt64(9)), // created,
+			stmt.ColumnInt(10),                  // articleId,
+			stmt.ColumnText(11),                 // text,
+		)
+		_, ok := userIndexer[user.Id]
+		if !ok {
+			userIndexer[user.Id] = len(users)
+			users = append(users, user)
+		}
+		_, ok = articleIndexer[article.Id]
+		if !ok {
+			articleIndexer[article.Id] = len(articles)
+			articles = append(articles, article)
+		}
+		_, ok = commentIndexer[comment.Id]
+		if !ok {
+			commentIndexer[comment.Id] = len(comments)
+			comments = append(comments, comment)
+		}
+		more, err = stmt.Step()
+		app.MustBeNil(err)
+	}
+	return users, articles, comments
+}
+
+func (d *dbImpl) ExecParams(execSql string, params [][]any) {
+	app.MustBeNil(d.TryExecParams(execSql, params))
+}
+
+func (d *dbImpl) TryExecParams(execSql string, params [][]any) error {
+	conn := d.get()
+	defer d.put(conn)
+	stmt, err := conn.Prepare(execSql)
+	if err != nil {
+		return codeError(err)
+	}
+	for _, p := range params {
+		bind(stmt, p)
+		_, err = stmt.Step()
+		if err == nil {
+			err = stmt.Reset()
+		}
+		if err != nil {
+			stmt.Finalize()
+			return codeError(err)
+		}
+	}
+	return codeError(stmt.Finalize())
+}
+
+// codeError adds the extended result code to err, see app.CodeError.
+func codeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &app.CodeError{Err: err, ExtendedCode: int(sqlite.ErrCode(err))}
+}
+
+func (d *dbImpl) QueryStrings(querySql string, params []any) []string {
+	conn := d.get()
+	defer d.put(conn)
+	stmt, err := conn.Prepare(querySql)
+	app.MustBeNil(err)
+	bind(stmt, params)
+	more, err := stmt.Step()
+	app.MustBeNil(err)
+	var values []string
+	for more {
+		values = append(values, stmt.ColumnText(0))
+		more, err = stmt.Step()
+		app.MustBeNil(err)
+	}
+	err = stmt.Finalize()
+	app.MustBeNil(err)
+	return values
+}
+
+func (d *dbImpl) FindUsersContext(ctx context.Context, querySql string) ([]app.User, error) {
+	conn := d.pool.Get(ctx) // interrupts conn when ctx is done
+	if conn == nil {
+		return nil, ctx.Err()
+	}
+	defer d.pool.Put(conn)
+	stmt, err := conn.Prepare(querySql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Finalize()
+	var users []app.User
+	for {
+		more, err := stmt.Step()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			break
+		}
+		user := app.NewUser(
+			stmt.ColumnInt(0),                   // id,
+			app.UnbindTime(stmt.ColumnInt64(1)), // created,
+			stmt.ColumnText(2),                  // email,
+			stmt.ColumnInt(3) != 0,              // active,
+		)
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (d *dbImpl) QueryStringsContext(ctx context.Context, querySql string, params []any) ([]string, error) {
+	conn := d.pool.Get(ctx) // interrupts conn when ctx is done
+	if conn == nil {
+		return nil, ctx.Err()
+	}
+	defer d.pool.Put(conn)
+	stmt, err := conn.Prepare(querySql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Finalize()
+	bind(stmt, params)
+	var values []string
+	for {
+		more, err := stmt.Step()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			break
+		}
+		values = append(values, stmt.ColumnText(0))
+	}
+	return values, nil
+}
+
+func (d *dbImpl) CreateScalarFunc(name string, fn func(x int64) int64) bool {
+	xFunc := func(ctx sqlite.Context, values ...sqlite.Value) {
+		ctx.ResultInt64(fn(values[0].Int64()))
+	}
+	d.all(func(conn *sqlite.Conn) {
+		err := conn.CreateFunction(name, true, 1, xFunc, nil, nil)
+		app.MustBeNil(err)
+	})
+	return true
+}
+
+func (d *dbImpl) CreateAggregateFunc(name string, newAggregate func() app.Aggregate) bool {
+	// craw keeps user data per function, not per invocation, so two
+	// invocations in one query, or the groups of a GROUP BY, would share
+	// one aggregate
+	return false
+}
+
+func (d *dbImpl) CreateCollation(name string, cmp func(a, b string) int) bool {
+	return false // craw does not support collations
+}
+
+func (d *dbImpl) WriteBlob(table, column string, rowid int64, src io.Reader, chunkSize int) bool {
+	conn := d.get()
+	defer d.put(conn)
+	blob, err := conn.OpenBlob("main", table, column, rowid, true)
+	app.MustBeNil(err)
+	app.MustBeNil(app.CopyChunks(blob, src, chunkSize))
+	app.MustBeNil(blob.Close())
+	return true
+}
+
+func (d *dbImpl) ReadBlob(table, column string, rowid int64, dst io.Writer, chunkSize int) bool {
+	conn := d.get()
+	defer d.put(conn)
+	blob, err := conn.OpenBlob("main", table, column, rowid, false)
+	app.MustBeNil(err)
+	app.MustBeNil(app.CopyChunks(dst, blob, chunkSize))
+	app.MustBeNil(blob.Close())
+	return true
+}
+
+func (d *dbImpl) Close() {
+	err := d.pool.Close()
+	app.MustBeNil(err)
+}
+
+// get returns the connection of the active tx, or a connection from the pool.
+func (d *dbImpl) get() *sqlite.Conn {
+	if d.txConn != nil {
+		return d.txConn
+	}
+	conn := d.pool.Get(context.TODO())
+	app.MustBeSet(conn)
+	return conn
+}
+
+// put returns a connection from get to the pool.
+func (d *dbImpl) put(conn *sqlite.Conn) {
+	if conn != d.txConn {
+		d.pool.Put(conn)
+	}
+}
+
+// all calls fn for every connection of the pool, for per-connection settings.
+func (d *dbImpl) all(fn func(conn *sqlite.Conn)) {
+	conns := make([]*sqlite.Conn, d.size)
+	for i := range conns {
+		conns[i] = d.get()
+	}
+	for _, conn := range conns {
+		fn(conn)
+		d.put(conn)
+	}
+}
+
+func isPragma(sql string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(sql)), "PRAGMA")
+}
+
+func (d *dbImpl) exec(conn *sqlite.Conn, sql string) {
+	stmt := conn.Prep(sql)
+	_, err := stmt.Step()
+	app.MustBeNil(err)
+	err = stmt.Finalize()
+	app.MustBeNil(err)
+}
+
+func bind(stmt *sqlite.Stmt, params []any) {
+	for i, p := range params {
+		switch v := p.(type) {
+		case nil:
+			stmt.BindNull(i + 1)
+		case int:
+			stmt.BindInt64(i+1, int64(v))
+		case int64:
+			stmt.BindInt64(i+1, v)
+		case float64:
+			stmt.BindFloat(i+1, v)
+		case bool:
+			stmt.BindBool(i+1, v)
+		case string:
+			stmt.BindText(i+1, v)
+		case []byte:
+			stmt.BindBytes(i+1, v)
+		default:
+			panic(fmt.Sprintf("cannot bind %T", p))
+		}
+	}
+}
+
+func (d *dbImpl) Backup(dstfile string, pagesPerStep int) bool {
+	conn := d.get()
+	defer d.put(conn)
+	dst, err := sqlite.OpenConn(dstfile, sqlite.SQLITE_OPEN_READWRITE|sqlite.SQLITE_OPEN_CREATE|sqlite.SQLITE_OPEN_URI|sqlite.SQLITE_OPEN_NOMUTEX)
+	app.MustBeNil(err)
+	defer func() { app.MustBeNil(dst.Close()) }()
+	b, err := conn.BackupInit("main", "main", dst)
+	app.MustBeNil(err)
+	for {
+		// Step does not tell SQLITE_OK from SQLITE_DONE, Remaining does
+		if err = b.Step(pagesPerStep); err != nil {
+			b.Finish()
+			app.MustBeNil(err)
+		}
+		if b.Remaining() == 0 {
+			break
+		}
+	}
+	app.MustBeNil(b.Finish())
+	return true
+}
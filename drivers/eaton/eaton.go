@@ -1,23 +1,23 @@
-package main
+// Package eaton is the eaton adapter of the benchmarks.
+package eaton
 
 import (
+	"context"
+	"io"
+	"iter"
+
 	"github.com/cvilsmeier/go-sqlite-bench/app"
 	"github.com/eatonphil/gosqlite"
 )
 
-func main() {
-	app.Run(func(dbfile string) app.Db {
-		return newDb(dbfile)
-	})
-}
-
 type dbImpl struct {
 	conn *gosqlite.Conn
 }
 
-var _ app.Db = (*dbImpl)(nil)
+var _ app.BackupDb = (*dbImpl)(nil)
 
-func newDb(dbfile string) app.Db {
+// New opens a Db for dbfile.
+func New(dbfile string) app.Db {
 	flags := gosqlite.OPEN_READWRITE |
 		gosqlite.OPEN_CREATE |
 		gosqlite.OPEN_URI |
@@ -105,6 +105,30 @@ func (d *dbImpl) FindUsers(querySql string) []app.User {
 	return users
 }
 
+func (d *dbImpl) StreamUsers(querySql string) iter.Seq[*app.User] {
+	return func(yield func(*app.User) bool) {
+		app.MustBeNil(d.conn.Begin())
+		defer func() { app.MustBeNil(d.conn.Commit()) }()
+		stmt := d.prepare(querySql)
+		defer func() { app.MustBeNil(stmt.Close()) }()
+		var user app.User
+		for {
+			hasRow, err := stmt.Step()
+			app.MustBeNil(err)
+			if !hasRow {
+				break
+			}
+			var createdInt int64
+			err = stmt.Scan(&user.Id, &createdInt, &user.Email, &user.Active)
+			app.MustBeNil(err)
+			user.Created = app.UnbindTime(createdInt)
+			if !yield(&user) {
+				return
+			}
+		}
+	}
+}
+
 func (d *dbImpl) FindArticles(querySql string) []app.Article {
 	app.MustBeNil(d.conn.Begin())
 	stmt := d.prepare(querySql)
@@ -176,7 +200,144 @@ func (d *dbImpl) FindUsersArticlesComments(querySql string, params []any) ([]app
 	return users, articles, comments
 }
 
+func (d *dbImpl) ExecParams(execSql string, params [][]any) {
+	app.MustBeNil(d.TryExecParams(execSql, params))
+}
+
+func (d *dbImpl) TryExecParams(execSql string, params [][]any) error {
+	stmt, err := d.conn.Prepare(execSql)
+	if err != nil {
+		return err
+	}
+	for _, p := range params {
+		if err = stmt.Exec(p...); err != nil {
+			stmt.Close()
+			return err
+		}
+	}
+	return stmt.Close()
+}
+
+func (d *dbImpl) QueryStrings(querySql string, params []any) []string {
+	stmt := d.prepare(querySql)
+	app.MustBeNil(stmt.Bind(params...))
+	var values []string
+	for {
+		hasRow, err := stmt.Step()
+		app.MustBeNil(err)
+		if !hasRow {
+			break
+		}
+		value, _, err := stmt.ColumnText(0)
+		app.MustBeNil(err)
+		values = append(values, value)
+	}
+	app.MustBeNil(stmt.Close())
+	return values
+}
+
+func (d *dbImpl) FindUsersContext(ctx context.Context, querySql string) ([]app.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer d.interruptWhenDone(ctx)()
+	stmt, err := d.conn.Prepare(querySql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	var users []app.User
+	for {
+		more, err := stmt.Step()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			break
+		}
+		var user app.User
+		var created int64
+		err = stmt.Scan(&user.Id, &created, &user.Email, &user.Active)
+		if err != nil {
+			return nil, err
+		}
+		user.Created = app.UnbindTime(created)
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (d *dbImpl) QueryStringsContext(ctx context.Context, querySql string, params []any) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer d.interruptWhenDone(ctx)()
+	stmt, err := d.conn.Prepare(querySql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	if len(params) > 0 {
+		err = stmt.Bind(params...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var values []string
+	for {
+		more, err := stmt.Step()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			break
+		}
+		value, _, err := stmt.ColumnText(0)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// interruptWhenDone interrupts the connection when ctx is done.
+// The returned func must be called when the database operation has ended,
+// it waits for a concurrent interrupt to complete.
+func (d *dbImpl) interruptWhenDone(ctx context.Context) func() {
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		d.conn.Interrupt()
+		close(interrupted)
+	})
+	return func() {
+		if !stop() {
+			<-interrupted
+		}
+	}
+}
+
 func (d *dbImpl) Close() {
 	err := d.conn.Close()
 	app.MustBeNil(err)
 }
+
+func (d *dbImpl) Backup(dstfile string, pagesPerStep int) bool {
+	dst, err := gosqlite.Open(dstfile, gosqlite.OPEN_READWRITE|gosqlite.OPEN_CREATE|gosqlite.OPEN_URI|gosqlite.OPEN_NOMUTEX)
+	app.MustBeNil(err)
+	defer func() { app.MustBeNil(dst.Close()) }()
+	b, err := d.conn.Backup("main", dst, "main")
+	app.MustBeNil(err)
+	for {
+		err = b.Step(pagesPerStep)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			b.Close()
+			app.MustBeNil(err)
+		}
+	}
+	app.MustBeNil(b.Close())
+	return true
+}
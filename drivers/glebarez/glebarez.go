@@ -0,0 +1,44 @@
+// Package glebarez is the glebarez adapter of the benchmarks.
+package glebarez
+
+import (
+	"database/sql/driver"
+
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+	"github.com/glebarez/go-sqlite"
+)
+
+// dbImpl is a SqlDb that registers Go functions with the driver.
+type dbImpl struct {
+	*app.SqlDb
+}
+
+var _ app.FuncDb = (*dbImpl)(nil)
+
+// New opens a Db for dbfile.
+func New(dbfile string) app.Db {
+	return &dbImpl{app.OpenSqlDb("glebarez", app.NewConnector(app.SqlDriver("sqlite"), dbfile))}
+}
+
+// glebarez registers functions for all connections that are opened
+// afterwards, and only once per name. Later registrations of the same
+// name are ignored, the first implementation stays in effect.
+var registered = map[string]bool{}
+
+func (d *dbImpl) CreateScalarFunc(name string, fn func(x int64) int64) bool {
+	if !registered[name] {
+		registered[name] = true
+		sqlite.MustRegisterDeterministicScalarFunction(name, 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			return fn(args[0].(int64)), nil
+		})
+	}
+	return true
+}
+
+func (d *dbImpl) CreateAggregateFunc(name string, newAggregate func() app.Aggregate) bool {
+	return false // glebarez supports scalar functions only
+}
+
+func (d *dbImpl) CreateCollation(name string, cmp func(a, b string) int) bool {
+	return false // glebarez supports scalar functions only
+}
@@ -0,0 +1,98 @@
+// Package mattn is the mattn adapter of the benchmarks.
+package mattn
+
+import (
+	"context"
+
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+	"github.com/mattn/go-sqlite3"
+)
+
+// dbImpl is a SqlDb that registers Go functions on each new connection.
+type dbImpl struct {
+	*app.SqlDb
+	connectHooks []func(conn *sqlite3.SQLiteConn) error
+}
+
+var _ app.FuncDb = (*dbImpl)(nil)
+var _ app.BackupDb = (*dbImpl)(nil)
+
+// New opens a Db for dbfile.
+func New(dbfile string) app.Db {
+	d := &dbImpl{}
+	drv := &sqlite3.SQLiteDriver{ConnectHook: d.connect}
+	d.SqlDb = app.OpenSqlDb("mattn", app.NewConnector(drv, dbfile))
+	return d
+}
+
+func (d *dbImpl) connect(conn *sqlite3.SQLiteConn) error {
+	for _, hook := range d.connectHooks {
+		if err := hook(conn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *dbImpl) CreateScalarFunc(name string, fn func(x int64) int64) bool {
+	d.connectHooks = append(d.connectHooks, func(conn *sqlite3.SQLiteConn) error {
+		return conn.RegisterFunc(name, fn, true)
+	})
+	return true
+}
+
+func (d *dbImpl) CreateAggregateFunc(name string, newAggregate func() app.Aggregate) bool {
+	d.connectHooks = append(d.connectHooks, func(conn *sqlite3.SQLiteConn) error {
+		return conn.RegisterAggregator(name, func() *aggregator {
+			return &aggregator{newAggregate()}
+		}, true)
+	})
+	return true
+}
+
+func (d *dbImpl) CreateCollation(name string, cmp func(a, b string) int) bool {
+	d.connectHooks = append(d.connectHooks, func(conn *sqlite3.SQLiteConn) error {
+		return conn.RegisterCollation(name, cmp)
+	})
+	return true
+}
+
+// aggregator adapts an app.Aggregate to the Step/Done methods of RegisterAggregator.
+type aggregator struct {
+	agg app.Aggregate
+}
+
+func (a *aggregator) Step(x int64) {
+	a.agg.Step(x)
+}
+
+func (a *aggregator) Done() int64 {
+	return a.agg.Final()
+}
+
+func (d *dbImpl) Backup(dstfile string, pagesPerStep int) bool {
+	conn, err := d.DB().Conn(context.Background())
+	app.MustBeNil(err)
+	defer conn.Close()
+	dst, err := (&sqlite3.SQLiteDriver{}).Open(dstfile)
+	app.MustBeNil(err)
+	defer dst.Close()
+	err = conn.Raw(func(driverConn any) error {
+		b, err := dst.(*sqlite3.SQLiteConn).Backup("main", driverConn.(*sqlite3.SQLiteConn), "main")
+		if err != nil {
+			return err
+		}
+		for {
+			done, err := b.Step(pagesPerStep)
+			if err != nil {
+				b.Finish()
+				return err
+			}
+			if done {
+				return b.Finish()
+			}
+		}
+	})
+	app.MustBeNil(err)
+	return true
+}
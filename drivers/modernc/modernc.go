@@ -0,0 +1,111 @@
+// Package modernc is the modernc adapter of the benchmarks.
+package modernc
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+	"modernc.org/sqlite"
+)
+
+// dbImpl is a SqlDb that registers Go functions with the driver.
+type dbImpl struct {
+	*app.SqlDb
+}
+
+var _ app.FuncDb = (*dbImpl)(nil)
+var _ app.BackupDb = (*dbImpl)(nil)
+
+// New opens a Db for dbfile.
+func New(dbfile string) app.Db {
+	return &dbImpl{app.OpenSqlDb("modernc", app.NewConnector(app.SqlDriver("sqlite"), dbfile))}
+}
+
+// modernc registers functions and collations for all connections that are
+// opened afterwards, and only once per name. Later registrations of the
+// same name are ignored, the first implementation stays in effect.
+var registered = map[string]bool{}
+
+func (d *dbImpl) CreateScalarFunc(name string, fn func(x int64) int64) bool {
+	if !registered[name] {
+		registered[name] = true
+		sqlite.MustRegisterFunction(name, &sqlite.FunctionImpl{
+			NArgs:         1,
+			Deterministic: true,
+			Scalar: func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+				return fn(args[0].(int64)), nil
+			},
+		})
+	}
+	return true
+}
+
+func (d *dbImpl) CreateAggregateFunc(name string, newAggregate func() app.Aggregate) bool {
+	if !registered[name] {
+		registered[name] = true
+		sqlite.MustRegisterFunction(name, &sqlite.FunctionImpl{
+			NArgs:         1,
+			Deterministic: true,
+			MakeAggregate: func(ctx sqlite.FunctionContext) (sqlite.AggregateFunction, error) {
+				return &aggregator{newAggregate()}, nil
+			},
+		})
+	}
+	return true
+}
+
+func (d *dbImpl) CreateCollation(name string, cmp func(a, b string) int) bool {
+	if !registered[name] {
+		registered[name] = true
+		sqlite.MustRegisterCollationUtf8(name, cmp)
+	}
+	return true
+}
+
+// aggregator adapts an app.Aggregate to a sqlite.AggregateFunction.
+type aggregator struct {
+	agg app.Aggregate
+}
+
+func (a *aggregator) Step(ctx *sqlite.FunctionContext, rowArgs []driver.Value) error {
+	a.agg.Step(rowArgs[0].(int64))
+	return nil
+}
+
+func (a *aggregator) WindowInverse(ctx *sqlite.FunctionContext, rowArgs []driver.Value) error {
+	return fmt.Errorf("window functions not supported")
+}
+
+func (a *aggregator) WindowValue(ctx *sqlite.FunctionContext) (driver.Value, error) {
+	return a.agg.Final(), nil
+}
+
+func (a *aggregator) Final(ctx *sqlite.FunctionContext) {}
+
+func (d *dbImpl) Backup(dstfile string, pagesPerStep int) bool {
+	conn, err := d.DB().Conn(context.Background())
+	app.MustBeNil(err)
+	defer conn.Close()
+	err = conn.Raw(func(driverConn any) error {
+		b, err := driverConn.(interface {
+			NewBackup(dstUri string) (*sqlite.Backup, error)
+		}).NewBackup(dstfile)
+		if err != nil {
+			return err
+		}
+		for {
+			more, err := b.Step(int32(pagesPerStep))
+			if err != nil {
+				b.Finish()
+				return err
+			}
+			if !more {
+				return b.Finish() // closes the destination connection
+			}
+		}
+	})
+	app.MustBeNil(err)
+	return true
+}
@@ -0,0 +1,153 @@
+// Package ncruces is the ncruces adapter of the benchmarks.
+package ncruces
+
+import (
+	"context"
+	sqldriver "database/sql/driver"
+	"io"
+
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+	"github.com/ncruces/go-sqlite3"
+	"github.com/ncruces/go-sqlite3/driver"
+)
+
+// dbImpl is a SqlDb that registers Go functions on each new connection.
+type dbImpl struct {
+	*app.SqlDb
+	initHooks []func(conn *sqlite3.Conn) error
+}
+
+var _ app.FuncDb = (*dbImpl)(nil)
+var _ app.BackupDb = (*dbImpl)(nil)
+var _ app.BlobDb = (*dbImpl)(nil)
+
+// New opens a Db for dbfile.
+func New(dbfile string) app.Db {
+	d := &dbImpl{}
+	c, err := (&driver.SQLite{}).OpenConnector(dbfile)
+	app.MustBeNil(err)
+	d.SqlDb = app.OpenSqlDb("ncruces", &connector{c, d.init})
+	return d
+}
+
+func (d *dbImpl) init(conn *sqlite3.Conn) error {
+	for _, hook := range d.initHooks {
+		if err := hook(conn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *dbImpl) CreateScalarFunc(name string, fn func(x int64) int64) bool {
+	d.initHooks = append(d.initHooks, func(conn *sqlite3.Conn) error {
+		return conn.CreateFunction(name, 1, sqlite3.DETERMINISTIC, func(ctx sqlite3.Context, arg ...sqlite3.Value) {
+			ctx.ResultInt64(fn(arg[0].Int64()))
+		})
+	})
+	return true
+}
+
+func (d *dbImpl) CreateAggregateFunc(name string, newAggregate func() app.Aggregate) bool {
+	d.initHooks = append(d.initHooks, func(conn *sqlite3.Conn) error {
+		return conn.CreateWindowFunction(name, 1, sqlite3.DETERMINISTIC, func() sqlite3.AggregateFunction {
+			return &aggregator{newAggregate()}
+		})
+	})
+	return true
+}
+
+func (d *dbImpl) CreateCollation(name string, cmp func(a, b string) int) bool {
+	d.initHooks = append(d.initHooks, func(conn *sqlite3.Conn) error {
+		return conn.CreateCollation(name, func(a, b []byte) int {
+			return cmp(string(a), string(b))
+		})
+	})
+	return true
+}
+
+// aggregator adapts an app.Aggregate to a sqlite3.AggregateFunction.
+type aggregator struct {
+	agg app.Aggregate
+}
+
+func (a *aggregator) Step(ctx sqlite3.Context, arg ...sqlite3.Value) {
+	a.agg.Step(arg[0].Int64())
+}
+
+func (a *aggregator) Value(ctx sqlite3.Context) {
+	ctx.ResultInt64(a.agg.Final())
+}
+
+// connector calls init on each new connection, like driver.Open does.
+type connector struct {
+	sqldriver.Connector
+	init func(conn *sqlite3.Conn) error
+}
+
+func (c *connector) Connect(ctx context.Context) (sqldriver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.init(conn.(interface{ Raw() *sqlite3.Conn }).Raw()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (d *dbImpl) Backup(dstfile string, pagesPerStep int) bool {
+	conn, err := d.DB().Conn(context.Background())
+	app.MustBeNil(err)
+	defer conn.Close()
+	err = conn.Raw(func(driverConn any) error {
+		b, err := driverConn.(interface{ Raw() *sqlite3.Conn }).Raw().BackupInit("main", dstfile)
+		if err != nil {
+			return err
+		}
+		for {
+			done, err := b.Step(pagesPerStep)
+			if err != nil {
+				b.Close()
+				return err
+			}
+			if done {
+				return b.Close() // closes the destination connection
+			}
+		}
+	})
+	app.MustBeNil(err)
+	return true
+}
+
+func (d *dbImpl) WriteBlob(table, column string, rowid int64, src io.Reader, chunkSize int) bool {
+	d.blob(table, column, rowid, true, func(blob *sqlite3.Blob) error {
+		return app.CopyChunks(blob, src, chunkSize)
+	})
+	return true
+}
+
+func (d *dbImpl) ReadBlob(table, column string, rowid int64, dst io.Writer, chunkSize int) bool {
+	d.blob(table, column, rowid, false, func(blob *sqlite3.Blob) error {
+		return app.CopyChunks(dst, blob, chunkSize)
+	})
+	return true
+}
+
+// blob opens a blob on the connection of the transaction, if one is
+// active, and calls f with it.
+func (d *dbImpl) blob(table, column string, rowid int64, write bool, f func(blob *sqlite3.Blob) error) {
+	err := d.Raw(func(driverConn any) error {
+		blob, err := driverConn.(interface{ Raw() *sqlite3.Conn }).Raw().OpenBlob("main", table, column, rowid, write)
+		if err != nil {
+			return err
+		}
+		if err = f(blob); err != nil {
+			blob.Close()
+			return err
+		}
+		return blob.Close()
+	})
+	app.MustBeNil(err)
+}
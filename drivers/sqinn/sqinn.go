@@ -1,23 +1,23 @@
-package main
+// Package sqinn is the sqinn adapter of the benchmarks.
+package sqinn
 
 import (
+	"context"
+	"fmt"
+	"iter"
+
 	"github.com/cvilsmeier/go-sqlite-bench/app"
 	"github.com/cvilsmeier/sqinn-go/v2"
 )
 
-func main() {
-	app.Run(func(dbfile string) app.Db {
-		return newDb(dbfile)
-	})
-}
-
 type dbImpl struct {
 	sq *sqinn.Sqinn
 }
 
 var _ app.Db = (*dbImpl)(nil)
 
-func newDb(dbfile string) app.Db {
+// New opens a Db for dbfile.
+func New(dbfile string) app.Db {
 	sq := sqinn.MustLaunch(sqinn.Options{Db: dbfile})
 	sq.MustExecSql("PRAGMA foreign_keys=1")
 	return &dbImpl{sq}
@@ -106,6 +106,26 @@ func (d *dbImpl) FindUsers(querySql string) []app.User {
 	return users
 }
 
+func (d *dbImpl) StreamUsers(querySql string) iter.Seq[*app.User] {
+	return func(yield func(*app.User) bool) {
+		coltypes := []byte{
+			sqinn.ValInt32, sqinn.ValInt64, sqinn.ValString, sqinn.ValInt32, // User
+		}
+		var user app.User
+		done := false // sqinn cannot stop a query early, remaining rows are skipped
+		err := d.sq.Query(querySql, nil, coltypes, func(row int, values []sqinn.Value) {
+			if done {
+				return
+			}
+			user = readUser(values, 0)
+			done = !yield(&user)
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
 func (d *dbImpl) FindUsersArticlesComments(querySql string, params []any) ([]app.User, []app.Article, []app.Comment) {
 	users := make([]app.User, 0, 2*1024)
 	articles := make([]app.Article, 0, 2*1024)
@@ -120,7 +140,7 @@ func (d *dbImpl) FindUsersArticlesComments(querySql string, params []any) ([]app
 	commentIds := map[int]struct{}{}
 	var sqinnParams []sqinn.Value
 	for _, p := range params {
-		sqinnParams = append(sqinnParams, sqinn.StringValue(p.(string)))
+		sqinnParams = append(sqinnParams, bindValue(p))
 	}
 	err := d.sq.Query(querySql, sqinnParams, coltypes, func(row int, values []sqinn.Value) {
 		user := readUser(values, 0)
@@ -145,6 +165,82 @@ func (d *dbImpl) FindUsersArticlesComments(querySql string, params []any) ([]app
 	return users, articles, comments
 }
 
+func (d *dbImpl) ExecParams(execSql string, params [][]any) {
+	if err := d.TryExecParams(execSql, params); err != nil {
+		panic(err)
+	}
+}
+
+func (d *dbImpl) TryExecParams(execSql string, params [][]any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	return d.sq.Exec(execSql, len(params), len(params[0]), func(iteration int, values []sqinn.Value) {
+		for i, p := range params[iteration] {
+			values[i] = bindValue(p)
+		}
+	})
+}
+
+func (d *dbImpl) QueryStrings(querySql string, params []any) []string {
+	var values []string
+	var sqinnParams []sqinn.Value
+	for _, p := range params {
+		sqinnParams = append(sqinnParams, bindValue(p))
+	}
+	err := d.sq.Query(querySql, sqinnParams, []byte{sqinn.ValString}, func(row int, rowValues []sqinn.Value) {
+		values = append(values, rowValues[0].String)
+	})
+	if err != nil {
+		panic(err)
+	}
+	return values
+}
+
+// sqinn runs SQLite in a child process and cannot interrupt a running
+// statement, so ctx is checked only before and after the statement.
+
+func (d *dbImpl) FindUsersContext(ctx context.Context, querySql string) ([]app.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var users []app.User
+	coltypes := []byte{
+		sqinn.ValInt32, sqinn.ValInt64, sqinn.ValString, sqinn.ValInt32, // User
+	}
+	err := d.sq.Query(querySql, nil, coltypes, func(row int, values []sqinn.Value) {
+		users = append(users, readUser(values, 0))
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (d *dbImpl) QueryStringsContext(ctx context.Context, querySql string, params []any) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var values []string
+	var sqinnParams []sqinn.Value
+	for _, p := range params {
+		sqinnParams = append(sqinnParams, bindValue(p))
+	}
+	err := d.sq.Query(querySql, sqinnParams, []byte{sqinn.ValString}, func(row int, rowValues []sqinn.Value) {
+		values = append(values, rowValues[0].String)
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
 func (d *dbImpl) Close() {
 	err := d.sq.Close()
 	app.MustBeNil(err)
@@ -177,6 +273,35 @@ func readComment(values []sqinn.Value, off int) app.Comment {
 	)
 }
 
+func bindValue(p any) sqinn.Value {
+	var value sqinn.Value
+	switch v := p.(type) {
+	case nil:
+		value.Type = sqinn.ValNull
+	case int:
+		value.Type = sqinn.ValInt64
+		value.Int64 = int64(v)
+	case int64:
+		value.Type = sqinn.ValInt64
+		value.Int64 = v
+	case float64:
+		value.Type = sqinn.ValDouble
+		value.Double = v
+	case bool:
+		value.Type = sqinn.ValInt32
+		value.Int32 = bindBool(v)
+	case string:
+		value.Type = sqinn.ValString
+		value.String = v
+	case []byte:
+		value.Type = sqinn.ValBlob
+		value.Blob = v
+	default:
+		panic(fmt.Sprintf("cannot bind %T", p))
+	}
+	return value
+}
+
 func bindBool(b bool) int {
 	if b {
 		return 1
@@ -0,0 +1,245 @@
+// Package queries is a query layer written like the code that sqlc
+// generates: a Queries type on top of a DBTX, one method per query, with
+// typed params and rows, and no prepared statements.
+// Unlike sqlc, the select methods take their SQL as an argument, because
+// the benchmarks pass their own queries. The scanning code is the same.
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"iter"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+func New(db DBTX) *Queries {
+	return &Queries{db: db}
+}
+
+type Queries struct {
+	db DBTX
+}
+
+func (q *Queries) WithTx(tx *sql.Tx) *Queries {
+	return &Queries{
+		db: tx,
+	}
+}
+
+type User struct {
+	ID      int64
+	Created int64
+	Email   string
+	Active  bool
+}
+
+const insertUser = `-- name: InsertUser :exec
+INSERT INTO users(id,created,email,active) VALUES(?,?,?,?)
+`
+
+type InsertUserParams struct {
+	ID      int64
+	Created int64
+	Email   string
+	Active  bool
+}
+
+func (q *Queries) InsertUser(ctx context.Context, arg InsertUserParams) error {
+	_, err := q.db.ExecContext(ctx, insertUser,
+		arg.ID,
+		arg.Created,
+		arg.Email,
+		arg.Active,
+	)
+	return err
+}
+
+const insertArticle = `-- name: InsertArticle :exec
+INSERT INTO articles(id,created,userId,text) VALUES(?,?,?,?)
+`
+
+type InsertArticleParams struct {
+	ID      int64
+	Created int64
+	UserID  int64
+	Text    string
+}
+
+func (q *Queries) InsertArticle(ctx context.Context, arg InsertArticleParams) error {
+	_, err := q.db.ExecContext(ctx, insertArticle,
+		arg.ID,
+		arg.Created,
+		arg.UserID,
+		arg.Text,
+	)
+	return err
+}
+
+const insertComment = `-- name: InsertComment :exec
+INSERT INTO comments(id,created,articleId,text) VALUES(?,?,?,?)
+`
+
+type InsertCommentParams struct {
+	ID        int64
+	Created   int64
+	ArticleID int64
+	Text      string
+}
+
+func (q *Queries) InsertComment(ctx context.Context, arg InsertCommentParams) error {
+	_, err := q.db.ExecContext(ctx, insertComment,
+		arg.ID,
+		arg.Created,
+		arg.ArticleID,
+		arg.Text,
+	)
+	return err
+}
+
+// -- name: ListUsers :many
+// SELECT id,created,email,active FROM users ...
+func (q *Queries) ListUsers(ctx context.Context, query string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Created,
+			&i.Email,
+			&i.Active,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// -- name: IterUsers :iter
+// SELECT id,created,email,active FROM users ...
+// sqlc has no :iter queries, IterUsers scans like ListUsers, but yields
+// each row instead of collecting them.
+func (q *Queries) IterUsers(ctx context.Context, query string) iter.Seq2[User, error] {
+	return func(yield func(User, error) bool) {
+		rows, err := q.db.QueryContext(ctx, query)
+		if err != nil {
+			yield(User{}, err)
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var i User
+			if err := rows.Scan(
+				&i.ID,
+				&i.Created,
+				&i.Email,
+				&i.Active,
+			); err != nil {
+				yield(User{}, err)
+				return
+			}
+			if !yield(i, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(User{}, err)
+		}
+	}
+}
+
+type ListUsersArticlesCommentsRow struct {
+	ID        int64
+	Created   int64
+	Email     string
+	Active    bool
+	ID_2      sql.NullInt64
+	Created_2 sql.NullInt64
+	UserID    sql.NullInt64
+	Text      sql.NullString
+	ID_3      sql.NullInt64
+	Created_3 sql.NullInt64
+	ArticleID sql.NullInt64
+	Text_2    sql.NullString
+}
+
+// -- name: ListUsersArticlesComments :many
+// SELECT users.*, articles.*, comments.* FROM users
+// LEFT JOIN articles ... LEFT JOIN comments ...
+func (q *Queries) ListUsersArticlesComments(ctx context.Context, query string, args ...interface{}) ([]ListUsersArticlesCommentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersArticlesCommentsRow
+	for rows.Next() {
+		var i ListUsersArticlesCommentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Created,
+			&i.Email,
+			&i.Active,
+			&i.ID_2,
+			&i.Created_2,
+			&i.UserID,
+			&i.Text,
+			&i.ID_3,
+			&i.Created_3,
+			&i.ArticleID,
+			&i.Text_2,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// -- name: ListStrings :many
+// SELECT ... (one column)
+func (q *Queries) ListStrings(ctx context.Context, query string, args ...interface{}) ([]sql.NullString, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullString
+	for rows.Next() {
+		var value sql.NullString
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
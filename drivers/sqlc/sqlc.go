@@ -0,0 +1,176 @@
+// Package sqlc is a layer on top of the database/sql adapters that runs
+// inserts and queries through a query layer like the one sqlc generates,
+// see package queries, to measure what such a layer adds.
+package sqlc
+
+import (
+	"context"
+	"iter"
+
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+	"github.com/cvilsmeier/go-sqlite-bench/drivers/sqlc/queries"
+)
+
+// dbImpl runs inserts and queries through queries.Queries and everything
+// else through the SqlLayer. The insert methods ignore insertSql, the
+// generated queries are the same.
+type dbImpl struct {
+	app.SqlLayer
+	q *queries.Queries
+}
+
+var _ app.FuncDb = (*dbImpl)(nil)
+var _ app.BackupDb = (*dbImpl)(nil)
+var _ app.BlobDb = (*dbImpl)(nil)
+var _ app.PoolDb = (*dbImpl)(nil)
+
+// Wrap returns a func that makes the Dbs of newDb, with inserts and
+// queries through the query layer. The driver name is the one of newDb,
+// with a "+sqlc" suffix.
+func Wrap(newDb func(dbfile string) app.Db) func(dbfile string) app.Db {
+	return func(dbfile string) app.Db {
+		db, ok := newDb(dbfile).(app.SqlAdapter)
+		app.Must(ok, "sqlc needs a database/sql adapter")
+		return &dbImpl{app.SqlLayer{SqlAdapter: db}, queries.New(db.DB())}
+	}
+}
+
+func (d *dbImpl) DriverName() string {
+	return d.SqlAdapter.DriverName() + "+sqlc"
+}
+
+// queries returns the queries of the transaction started by Begin, if one
+// is active.
+func (d *dbImpl) queries() *queries.Queries {
+	if tx := d.Tx(); tx != nil {
+		return d.q.WithTx(tx)
+	}
+	return d.q
+}
+
+// txQueries returns the queries of the transaction started by Begin.
+func (d *dbImpl) txQueries() *queries.Queries {
+	tx := d.Tx()
+	if tx == nil {
+		panic("no transaction, Begin was not called")
+	}
+	return d.q.WithTx(tx)
+}
+
+func (d *dbImpl) InsertUsers(insertSql string, users []app.User) {
+	q := d.txQueries()
+	for _, u := range users {
+		err := q.InsertUser(context.Background(), queries.InsertUserParams{
+			ID:      int64(u.Id),
+			Created: app.BindTime(u.Created),
+			Email:   u.Email,
+			Active:  u.Active,
+		})
+		app.MustBeNil(err)
+	}
+}
+
+func (d *dbImpl) InsertArticles(insertSql string, articles []app.Article) {
+	q := d.txQueries()
+	for _, a := range articles {
+		err := q.InsertArticle(context.Background(), queries.InsertArticleParams{
+			ID:      int64(a.Id),
+			Created: app.BindTime(a.Created),
+			UserID:  int64(a.UserId),
+			Text:    a.Text,
+		})
+		app.MustBeNil(err)
+	}
+}
+
+func (d *dbImpl) InsertComments(insertSql string, comments []app.Comment) {
+	q := d.txQueries()
+	for _, c := range comments {
+		err := q.InsertComment(context.Background(), queries.InsertCommentParams{
+			ID:        int64(c.Id),
+			Created:   app.BindTime(c.Created),
+			ArticleID: int64(c.ArticleId),
+			Text:      c.Text,
+		})
+		app.MustBeNil(err)
+	}
+}
+
+func (d *dbImpl) FindUsers(querySql string) []app.User {
+	users, err := d.FindUsersContext(context.Background(), querySql)
+	app.MustBeNil(err)
+	return users
+}
+
+func (d *dbImpl) StreamUsers(querySql string) iter.Seq[*app.User] {
+	return func(yield func(*app.User) bool) {
+		var u app.User
+		for row, err := range d.q.IterUsers(context.Background(), querySql) {
+			app.MustBeNil(err)
+			u = toUser(row)
+			if !yield(&u) {
+				return
+			}
+		}
+	}
+}
+
+func (d *dbImpl) FindUsersArticlesComments(querySql string, params []any) ([]app.User, []app.Article, []app.Comment) {
+	rows, err := d.q.ListUsersArticlesComments(context.Background(), querySql, params...)
+	app.MustBeNil(err)
+	var users []app.User
+	var articles []app.Article
+	var comments []app.Comment
+	seenUsers := make(map[int64]bool)
+	seenArticles := make(map[int64]bool)
+	seenComments := make(map[int64]bool)
+	for _, row := range rows {
+		if !seenUsers[row.ID] {
+			seenUsers[row.ID] = true
+			users = append(users, app.NewUser(int(row.ID), app.UnbindTime(row.Created), row.Email, row.Active))
+		}
+		if !seenArticles[row.ID_2.Int64] {
+			seenArticles[row.ID_2.Int64] = true
+			articles = append(articles, app.NewArticle(int(row.ID_2.Int64), app.UnbindTime(row.Created_2.Int64), int(row.UserID.Int64), row.Text.String))
+		}
+		if !seenComments[row.ID_3.Int64] {
+			seenComments[row.ID_3.Int64] = true
+			comments = append(comments, app.NewComment(int(row.ID_3.Int64), app.UnbindTime(row.Created_3.Int64), int(row.ArticleID.Int64), row.Text_2.String))
+		}
+	}
+	return users, articles, comments
+}
+
+func (d *dbImpl) QueryStrings(querySql string, params []any) []string {
+	values, err := d.QueryStringsContext(context.Background(), querySql, params)
+	app.MustBeNil(err)
+	return values
+}
+
+func (d *dbImpl) FindUsersContext(ctx context.Context, querySql string) ([]app.User, error) {
+	rows, err := d.q.ListUsers(ctx, querySql)
+	if err != nil {
+		return nil, err
+	}
+	var users []app.User
+	for _, row := range rows {
+		users = append(users, toUser(row))
+	}
+	return users, nil
+}
+
+func (d *dbImpl) QueryStringsContext(ctx context.Context, querySql string, params []any) ([]string, error) {
+	rows, err := d.queries().ListStrings(ctx, querySql, params...)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, row := range rows {
+		values = append(values, row.String)
+	}
+	return values, nil
+}
+
+func toUser(row queries.User) app.User {
+	return app.NewUser(int(row.ID), app.UnbindTime(row.Created), row.Email, row.Active)
+}
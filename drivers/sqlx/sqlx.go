@@ -0,0 +1,172 @@
+// Package sqlx is a layer on top of the database/sql adapters that runs
+// queries through github.com/jmoiron/sqlx, to measure what sqlx adds.
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"iter"
+
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+	"github.com/jmoiron/sqlx"
+)
+
+// dbImpl runs queries through sqlx and everything else through the
+// SqlLayer. Inserts use prepared statements, sqlx adds nothing to these.
+type dbImpl struct {
+	app.SqlLayer
+	x *sqlx.DB
+}
+
+var _ app.FuncDb = (*dbImpl)(nil)
+var _ app.BackupDb = (*dbImpl)(nil)
+var _ app.BlobDb = (*dbImpl)(nil)
+var _ app.PoolDb = (*dbImpl)(nil)
+
+// Wrap returns a func that makes the Dbs of newDb, with queries through
+// sqlx. The driver name is the one of newDb, with a "+sqlx" suffix.
+func Wrap(newDb func(dbfile string) app.Db) func(dbfile string) app.Db {
+	return func(dbfile string) app.Db {
+		db, ok := newDb(dbfile).(app.SqlAdapter)
+		app.Must(ok, "sqlx needs a database/sql adapter")
+		return &dbImpl{app.SqlLayer{SqlAdapter: db}, sqlx.NewDb(db.DB(), "sqlite3")}
+	}
+}
+
+// user is a users row for StructScan. sqlx maps the columns to the fields
+// of app.User by their lowercase names, but created is stored as
+// app.BindTime, so it is scanned into Created, which shadows User.Created.
+type user struct {
+	app.User
+	Created int64
+}
+
+func (u *user) toUser() app.User {
+	u.User.Created = app.UnbindTime(u.Created)
+	return u.User
+}
+
+func (d *dbImpl) DriverName() string {
+	return d.SqlAdapter.DriverName() + "+sqlx"
+}
+
+func (d *dbImpl) FindUsers(querySql string) []app.User {
+	var rows []user
+	app.MustBeNil(d.x.Select(&rows, querySql))
+	return toUsers(rows)
+}
+
+func (d *dbImpl) StreamUsers(querySql string) iter.Seq[*app.User] {
+	return func(yield func(*app.User) bool) {
+		rows, err := d.x.Queryx(querySql)
+		app.MustBeNil(err)
+		defer rows.Close()
+		var row user
+		for rows.Next() {
+			app.MustBeNil(rows.StructScan(&row))
+			u := row.toUser()
+			if !yield(&u) {
+				return
+			}
+		}
+		app.MustBeNil(rows.Err())
+	}
+}
+
+// FindUsersArticlesComments scans positionally: the joined columns have
+// the same names (id, created, ...), which StructScan cannot tell apart,
+// and the LEFT JOINs make them nullable.
+func (d *dbImpl) FindUsersArticlesComments(querySql string, params []any) ([]app.User, []app.Article, []app.Comment) {
+	rows, err := d.x.Queryx(querySql, params...)
+	app.MustBeNil(err)
+	defer rows.Close()
+	var u user
+	var articleId, articleCreated, articleUserId sql.NullInt64
+	var articleText sql.NullString
+	var commentId, commentCreated, commentArticleId sql.NullInt64
+	var commentText sql.NullString
+	var users []app.User
+	var articles []app.Article
+	var comments []app.Comment
+	seenUsers := make(map[int]bool)
+	seenArticles := make(map[int]bool)
+	seenComments := make(map[int]bool)
+	for rows.Next() {
+		err = rows.Scan(&u.Id, &u.Created, &u.Email, &u.Active,
+			&articleId, &articleCreated, &articleUserId, &articleText,
+			&commentId, &commentCreated, &commentArticleId, &commentText)
+		app.MustBeNil(err)
+		if !seenUsers[u.Id] {
+			seenUsers[u.Id] = true
+			users = append(users, u.toUser())
+		}
+		if id := int(articleId.Int64); !seenArticles[id] {
+			seenArticles[id] = true
+			articles = append(articles, app.NewArticle(id, app.UnbindTime(articleCreated.Int64), int(articleUserId.Int64), articleText.String))
+		}
+		if id := int(commentId.Int64); !seenComments[id] {
+			seenComments[id] = true
+			comments = append(comments, app.NewComment(id, app.UnbindTime(commentCreated.Int64), int(commentArticleId.Int64), commentText.String))
+		}
+	}
+	app.MustBeNil(rows.Err())
+	return users, articles, comments
+}
+
+func (d *dbImpl) QueryStrings(querySql string, params []any) []string {
+	values, err := d.QueryStringsContext(context.Background(), querySql, params)
+	app.MustBeNil(err)
+	return values
+}
+
+// queryer returns the transaction started by Begin, if one is active,
+// or the database.
+func (d *dbImpl) queryer() sqlx.QueryerContext {
+	if tx := d.Tx(); tx != nil {
+		return &sqlx.Tx{Tx: tx, Mapper: d.x.Mapper}
+	}
+	return d.x
+}
+
+func (d *dbImpl) FindUsersContext(ctx context.Context, querySql string) ([]app.User, error) {
+	var rows []user
+	if err := d.x.SelectContext(ctx, &rows, querySql); err != nil {
+		return nil, err
+	}
+	return toUsers(rows), nil
+}
+
+// QueryStringsContext scans the first column and discards the others,
+// sqlx cannot select rows of more than one column into strings.
+func (d *dbImpl) QueryStringsContext(ctx context.Context, querySql string, params []any) ([]string, error) {
+	rows, err := d.queryer().QueryxContext(ctx, querySql, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var value sql.NullString
+	dest := []any{&value}
+	for range columns[1:] {
+		dest = append(dest, new(any))
+	}
+	var values []string
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		values = append(values, value.String)
+	}
+	return values, rows.Err()
+}
+
+func toUsers(rows []user) []app.User {
+	var users []app.User
+	for i := range rows {
+		users = append(users, rows[i].toUser())
+	}
+	return users
+}
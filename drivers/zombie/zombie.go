@@ -0,0 +1,600 @@
+// Package zombie is the zombie adapter of the benchmarks.
+package zombie
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+
+	"github.com/cvilsmeier/go-sqlite-bench/app"
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// conns is where a dbImpl takes its connections from: one connection, see
+// New, or a pool, see NewPool.
+type conns interface {
+	take(ctx context.Context) (*sqlite.Conn, error) // interrupts the conn when ctx is done, until put
+	put(conn *sqlite.Conn)
+	size() int
+	close() error
+}
+
+type dbImpl struct {
+	conns  conns
+	txConn *sqlite.Conn // or nil if no tx active right now
+}
+
+var _ app.FuncDb = (*dbImpl)(nil)
+var _ app.BackupDb = (*dbImpl)(nil)
+var _ app.BlobDb = (*dbImpl)(nil)
+
+// poolDbImpl is a dbImpl with a pool of app.PoolSize connections.
+type poolDbImpl struct {
+	*dbImpl
+}
+
+var _ app.PoolDb = (*poolDbImpl)(nil)
+
+// New opens a Db for dbfile, with one connection.
+func New(dbfile string) app.Db {
+	conn, err := sqlite.OpenConn(dbfile, sqlite.OpenReadWrite, sqlite.OpenCreate, sqlite.OpenURI, sqlite.OpenPrivateCache)
+	app.MustBeNil(err)
+	return &dbImpl{conns: &singleConn{conn: conn}}
+}
+
+// NewPool opens a Db for dbfile, with a pool of app.PoolSize connections.
+func NewPool(dbfile string) app.Db {
+	if dbfile == ":memory:" {
+		dbfile = "file::memory:?mode=memory" // sqlitex rejects ":memory:" for pools
+	}
+	size := app.PoolSize()
+	pool, err := sqlitex.NewPool(dbfile, sqlitex.PoolOptions{
+		Flags:    sqlite.OpenReadWrite | sqlite.OpenCreate | sqlite.OpenURI | sqlite.OpenPrivateCache,
+		PoolSize: size,
+	})
+	app.MustBeNil(err)
+	return &poolDbImpl{&dbImpl{conns: &poolConns{pool, size}}}
+}
+
+func (d *dbImpl) DriverName() string {
+	return "zombie"
+}
+
+func (d *poolDbImpl) DriverName() string {
+	return "zombie-pool"
+}
+
+func (d *poolDbImpl) PoolSize() int {
+	return d.conns.size()
+}
+
+func (d *dbImpl) Exec(sqls ...string) {
+	for _, s := range sqls {
+		if d.txConn == nil && isPragma(s) {
+			d.all(func(conn *sqlite.Conn) { d.exec(conn, s) }) // pragmas are per connection
+			continue
+		}
+		conn := d.get()
+		d.exec(conn, s)
+		d.put(conn)
+	}
+}
+
+func (d *dbImpl) Begin() {
+	conn := d.get()
+	d.exec(conn, "BEGIN")
+	d.txConn = conn // all statements use conn until Commit
+}
+
+func (d *dbImpl) Commit() {
+	conn := d.txConn
+	d.exec(conn, "COMMIT")
+	d.txConn = nil
+	d.put(conn)
+}
+
+func (d *dbImpl) InsertUsers(insertSql string, users []app.User) {
+	conn := d.get()
+	defer d.put(conn)
+	stmt := conn.Prep(insertSql)
+	for _, u := range users {
+		stmt.BindInt64(1, int64(u.Id))
+		stmt.BindInt64(2, app.BindTime(u.Created))
+		stmt.BindText(3, u.Email)
+		stmt.BindBool(4, u.Active)
+		_, err := stmt.Step()
+		app.MustBeNil(err)
+		err = stmt.Reset()
+		app.MustBeNil(err)
+	}
+	err := stmt.Finalize()
+	app.MustBeNil(err)
+}
+
+func (d *dbImpl) InsertArticles(insertSql string, articles []app.Article) {
+	conn := d.get()
+	defer d.put(conn)
+	stmt := conn.Prep(insertSql)
+	for _, u := range articles {
+		stmt.BindInt64(1, int64(u.Id))
+		stmt.BindInt64(2, app.BindTime(u.Created))
+		stmt.BindInt64(3, int64(u.UserId))
+		stmt.BindText(4, u.Text)
+		_, err := stmt.Step()
+		app.MustBeNil(err)
+		err = stmt.Reset()
+		app.MustBeNil(err)
+	}
+	err := stmt.Finalize()
+	app.MustBeNil(err)
+}
+
+func (d *dbImpl) InsertComments(insertSql string, comments []app.Comment) {
+	conn := d.get()
+	defer d.put(conn)
+	stmt := conn.Prep(insertSql)
+	for _, u := range comments {
+		stmt.BindInt64(1, int64(u.Id))
+		stmt.BindInt64(2, app.BindTime(u.Created))
+		stmt.BindInt64(3, int64(u.ArticleId))
+		stmt.BindText(4, u.Text)
+		_, err := stmt.Step()
+		app.MustBeNil(err)
+		err = stmt.Reset()
+		app.MustBeNil(err)
+	}
+	err := stmt.Finalize()
+	app.MustBeNil(err)
+}
+
+func (d *dbImpl) FindUsers(querySql string) []app.User {
+	conn := d.get()
+	defer d.put(conn)
+	stmt, err := conn.Prepare(querySql)
+	app.MustBeNil(err)
+	more, err := stmt.Step()
+	app.MustBeNil(err)
+	var users []app.User
+	for more {
+		user := app.NewUser(
+			stmt.ColumnInt(0),                   // id,
+			app.UnbindTime(stmt.ColumnInt64(1)), // created,
+			stmt.ColumnText(2),                  // email,
+			stmt.ColumnInt(3) != 0,              // active,
+		)
+		users = append(users, user)
+		more, err = stmt.Step()
+		app.MustBeNil(err)
+	}
+	return users
+}
+
+func (d *dbImpl) StreamUsers(querySql string) iter.Seq[*app.User] {
+	return func(yield func(*app.User) bool) {
+		conn := d.get()
+		defer d.put(conn)
+		stmt, err := conn.Prepare(querySql)
+		app.MustBeNil(err)
+		defer stmt.Finalize()
+		more, err := stmt.Step()
+		app.MustBeNil(err)
+		var user app.User
+		for more {
+			user = app.NewUser(
+				stmt.ColumnInt(0),                   // id,
+				app.UnbindTime(stmt.ColumnInt64(1)), // created,
+				stmt.ColumnText(2),                  // email,
+				stmt.ColumnInt(3) != 0,              // active,
+			)
+			if !yield(&user) {
+				return
+			}
+			more, err = stmt.Step()
+			app.MustBeNil(err)
+		}
+	}
+}
+
+func (d *dbImpl) FindArticles(querySql string) []app.Article {
+	conn := d.get()
+	defer d.put(conn)
+	stmt, err := conn.Prepare(querySql)
+	app.MustBeNil(err)
+	more, err := stmt.Step()
+	app.MustBeNil(err)
+	var articles []app.Article
+	for more {
+		article := app.NewArticle(
+			stmt.ColumnInt(0),                   // id,
+			app.UnbindTime(stmt.ColumnInt64(1)), // created,
+			stmt.ColumnInt(2),                   // userId,
+			stmt.ColumnText(3),                  // text,
+		)
+		articles = append(articles, article)
+		more, err = stmt.Step()
+		app.MustBeNil(err)
+	}
+	return articles
+}
+
+func (d *dbImpl) FindUsersArticlesComments(querySql string, params []any) ([]app.User, []app.Article, []app.Comment) {
+	conn := d.get()
+	defer d.put(conn)
+	stmt, err := conn.Prepare(querySql)
+	app.MustBeNil(err)
+	bind(stmt, params)
+	more, err := stmt.Step()
+	app.MustBeNil(err)
+	// collections
+	var users []app.User
+	userIndexer := make(map[int]int)
+	var articles []app.Article
+	articleIndexer := make(map[int]int)
+	var comments []app.Comment
+	commentIndexer := make(map[int]int)
+	for more {
+		user := app.NewUser(
+			stmt.ColumnInt(0),                   // id,
+			app.UnbindTime(stmt.ColumnInt64(1)), // created,
+			stmt.ColumnText(2),                  // email,
+			stmt.ColumnInt(3) != 0,              // active,
+		)
+		article := app.NewArticle(
+			stmt.ColumnInt(4),                   // id,
+			app.UnbindTime(stmt.ColumnInt64(5)), // created,
+			stmt.ColumnInt(6),                   // userId,
+			stmt.ColumnText(7),                  // text,
+		)
+		comment := app.NewComment(
+			stmt.ColumnInt(8),                   // id,
+			app.UnbindTime(stmt.ColumnInt64(9)), // created,
+			stmt.ColumnInt(10),                  // articleId,
+			stmt.ColumnText(11),                 // text,
+		)
+		_, ok := userIndexer[user.Id]
+		if !ok {
+			userIndexer[user.Id] = len(users)
+			users = append(users, user)
+		}
+		_, ok = articleIndexer[article.Id]
+		if !ok {
+			articleIndexer[article.Id] = len(articles)
+			articles = append(articles, article)
+		}
+		_, ok = commentIndexer[comment.Id]
+		if !ok {
+			commentIndexer[comment.Id] = len(comments)
+			comments = append(comments, comment)
+		}
+		more, err = stmt.Step()
+		app.MustBeNil(err)
+	}
+	return users, articles, comments
+}
+
+func (d *dbImpl) ExecParams(execSql string, params [][]any) {
+	app.MustBeNil(d.TryExecParams(execSql, params))
+}
+
+func (d *dbImpl) TryExecParams(execSql string, params [][]any) error {
+	conn := d.get()
+	defer d.put(conn)
+	stmt, err := conn.Prepare(execSql)
+	if err != nil {
+		return codeError(err)
+	}
+	for _, p := range params {
+		bind(stmt, p)
+		_, err = stmt.Step()
+		if err == nil {
+			err = stmt.Reset()
+		}
+		if err != nil {
+			stmt.Finalize()
+			return codeError(err)
+		}
+	}
+	return codeError(stmt.Finalize())
+}
+
+// codeError adds the extended result code to err, see app.CodeError.
+func codeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &app.CodeError{Err: err, ExtendedCode: int(sqlite.ErrCode(err))}
+}
+
+func (d *dbImpl) QueryStrings(querySql string, params []any) []string {
+	conn := d.get()
+	defer d.put(conn)
+	stmt, err := conn.Prepare(querySql)
+	app.MustBeNil(err)
+	bind(stmt, params)
+	more, err := stmt.Step()
+	app.MustBeNil(err)
+	var values []string
+	for more {
+		values = append(values, stmt.ColumnText(0))
+		more, err = stmt.Step()
+		app.MustBeNil(err)
+	}
+	err = stmt.Finalize()
+	app.MustBeNil(err)
+	return values
+}
+
+func (d *dbImpl) FindUsersContext(ctx context.Context, querySql string) ([]app.User, error) {
+	conn, err := d.conns.take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer d.conns.put(conn)
+	stmt, err := conn.Prepare(querySql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Finalize()
+	var users []app.User
+	for {
+		more, err := stmt.Step()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			break
+		}
+		user := app.NewUser(
+			stmt.ColumnInt(0),                   // id,
+			app.UnbindTime(stmt.ColumnInt64(1)), // created,
+			stmt.ColumnText(2),                  // email,
+			stmt.ColumnInt(3) != 0,              // active,
+		)
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (d *dbImpl) QueryStringsContext(ctx context.Context, querySql string, params []any) ([]string, error) {
+	conn, err := d.conns.take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer d.conns.put(conn)
+	stmt, err := conn.Prepare(querySql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Finalize()
+	bind(stmt, params)
+	var values []string
+	for {
+		more, err := stmt.Step()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			break
+		}
+		values = append(values, stmt.ColumnText(0))
+	}
+	return values, nil
+}
+
+func (d *dbImpl) CreateScalarFunc(name string, fn func(x int64) int64) bool {
+	d.all(func(conn *sqlite.Conn) {
+		err := conn.CreateFunction(name, &sqlite.FunctionImpl{
+			NArgs:         1,
+			Deterministic: true,
+			Scalar: func(ctx sqlite.Context, args []sqlite.Value) (sqlite.Value, error) {
+				return sqlite.IntegerValue(fn(args[0].Int64())), nil
+			},
+		})
+		app.MustBeNil(err)
+	})
+	return true
+}
+
+func (d *dbImpl) CreateAggregateFunc(name string, newAggregate func() app.Aggregate) bool {
+	d.all(func(conn *sqlite.Conn) {
+		err := conn.CreateFunction(name, &sqlite.FunctionImpl{
+			NArgs:         1,
+			Deterministic: true,
+			MakeAggregate: func(ctx sqlite.Context) (sqlite.AggregateFunction, error) {
+				return &aggregator{newAggregate()}, nil
+			},
+		})
+		app.MustBeNil(err)
+	})
+	return true
+}
+
+func (d *dbImpl) CreateCollation(name string, cmp func(a, b string) int) bool {
+	d.all(func(conn *sqlite.Conn) {
+		err := conn.SetCollation(name, cmp)
+		app.MustBeNil(err)
+	})
+	return true
+}
+
+func (d *dbImpl) WriteBlob(table, column string, rowid int64, src io.Reader, chunkSize int) bool {
+	conn := d.get()
+	defer d.put(conn)
+	blob, err := conn.OpenBlob("main", table, column, rowid, true)
+	app.MustBeNil(err)
+	app.MustBeNil(app.CopyChunks(blob, src, chunkSize))
+	app.MustBeNil(blob.Close())
+	return true
+}
+
+func (d *dbImpl) ReadBlob(table, column string, rowid int64, dst io.Writer, chunkSize int) bool {
+	conn := d.get()
+	defer d.put(conn)
+	blob, err := conn.OpenBlob("main", table, column, rowid, false)
+	app.MustBeNil(err)
+	app.MustBeNil(app.CopyChunks(dst, blob, chunkSize))
+	app.MustBeNil(blob.Close())
+	return true
+}
+
+func (d *dbImpl) Close() {
+	err := d.conns.close()
+	app.MustBeNil(err)
+}
+
+// get returns the connection of the active tx, or a connection from the pool.
+func (d *dbImpl) get() *sqlite.Conn {
+	if d.txConn != nil {
+		return d.txConn
+	}
+	conn, err := d.conns.take(context.TODO())
+	app.MustBeNil(err)
+	return conn
+}
+
+// put returns a connection from get to the pool.
+func (d *dbImpl) put(conn *sqlite.Conn) {
+	if conn != d.txConn {
+		d.conns.put(conn)
+	}
+}
+
+// all calls fn for every connection of the pool, for per-connection settings.
+func (d *dbImpl) all(fn func(conn *sqlite.Conn)) {
+	conns := make([]*sqlite.Conn, d.conns.size())
+	for i := range conns {
+		conns[i] = d.get()
+	}
+	for _, conn := range conns {
+		fn(conn)
+		d.put(conn)
+	}
+}
+
+// singleConn is the one connection of New.
+type singleConn struct {
+	conn        *sqlite.Conn
+	interrupted bool // conn is interrupted when the ctx of take is done
+}
+
+func (c *singleConn) take(ctx context.Context) (*sqlite.Conn, error) {
+	if ctx.Done() != nil {
+		c.conn.SetInterrupt(ctx.Done())
+		c.interrupted = true
+	}
+	return c.conn, nil
+}
+
+func (c *singleConn) put(conn *sqlite.Conn) {
+	if c.interrupted {
+		c.conn.SetInterrupt(nil)
+		c.interrupted = false
+	}
+}
+
+func (c *singleConn) size() int {
+	return 1
+}
+
+func (c *singleConn) close() error {
+	return c.conn.Close()
+}
+
+// poolConns is the pool of NewPool.
+type poolConns struct {
+	pool *sqlitex.Pool
+	n    int
+}
+
+func (c *poolConns) take(ctx context.Context) (*sqlite.Conn, error) {
+	return c.pool.Take(ctx)
+}
+
+func (c *poolConns) put(conn *sqlite.Conn) {
+	c.pool.Put(conn)
+}
+
+func (c *poolConns) size() int {
+	return c.n
+}
+
+func (c *poolConns) close() error {
+	return c.pool.Close()
+}
+
+func isPragma(sql string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(sql)), "PRAGMA")
+}
+
+func (d *dbImpl) exec(conn *sqlite.Conn, sql string) {
+	stmt := conn.Prep(sql)
+	app.MustBeSet(stmt)
+	_, err := stmt.Step()
+	app.MustBeNil(err)
+	err = stmt.Finalize()
+	app.MustBeNil(err)
+}
+
+func bind(stmt *sqlite.Stmt, params []any) {
+	for i, p := range params {
+		switch v := p.(type) {
+		case nil:
+			stmt.BindNull(i + 1)
+		case int:
+			stmt.BindInt64(i+1, int64(v))
+		case int64:
+			stmt.BindInt64(i+1, v)
+		case float64:
+			stmt.BindFloat(i+1, v)
+		case bool:
+			stmt.BindBool(i+1, v)
+		case string:
+			stmt.BindText(i+1, v)
+		case []byte:
+			stmt.BindBytes(i+1, v)
+		default:
+			panic(fmt.Sprintf("cannot bind %T", p))
+		}
+	}
+}
+
+// aggregator adapts an app.Aggregate to a sqlite.AggregateFunction.
+type aggregator struct {
+	agg app.Aggregate
+}
+
+func (a *aggregator) Step(ctx sqlite.Context, rowArgs []sqlite.Value) error {
+	a.agg.Step(rowArgs[0].Int64())
+	return nil
+}
+
+func (a *aggregator) WindowInverse(ctx sqlite.Context, rowArgs []sqlite.Value) error {
+	return fmt.Errorf("window functions not supported")
+}
+
+func (a *aggregator) WindowValue(ctx sqlite.Context) (sqlite.Value, error) {
+	return sqlite.IntegerValue(a.agg.Final()), nil
+}
+
+func (a *aggregator) Finalize(ctx sqlite.Context) {}
+
+func (d *dbImpl) Backup(dstfile string, pagesPerStep int) bool {
+	conn := d.get()
+	defer d.put(conn)
+	dst, err := sqlite.OpenConn(dstfile, sqlite.OpenReadWrite|sqlite.OpenCreate|sqlite.OpenURI)
+	app.MustBeNil(err)
+	defer func() { app.MustBeNil(dst.Close()) }()
+	b, err := sqlite.NewBackup(dst, "main", conn, "main")
+	app.MustBeNil(err)
+	defer b.Close()
+	for {
+		more, err := b.Step(pagesPerStep)
+		app.MustBeNil(err)
+		if !more {
+			return true
+		}
+	}
+}
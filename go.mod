@@ -8,6 +8,7 @@ require (
 	github.com/cvilsmeier/sqinn-go/v2 v2.1.3
 	github.com/eatonphil/gosqlite v0.10.0
 	github.com/glebarez/go-sqlite v1.22.0
+	github.com/jmoiron/sqlx v1.4.0
 	github.com/mattn/go-sqlite3 v1.14.37
 	github.com/ncruces/go-sqlite3 v0.33.0
 	modernc.org/sqlite v1.47.0
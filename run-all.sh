@@ -15,7 +15,11 @@ date && ./bench-craw     bench.db  && ./bench-craw     bench.db
 date && ./bench-eaton    bench.db  && ./bench-eaton    bench.db
 date && ./bench-glebarez bench.db  && ./bench-glebarez bench.db
 date && ./bench-mattn    bench.db  && ./bench-mattn    bench.db
+date && ./bench-mattn-sqlx bench.db && ./bench-mattn-sqlx bench.db
+date && ./bench-mattn-sqlc bench.db && ./bench-mattn-sqlc bench.db
 date && ./bench-modernc  bench.db  && ./bench-modernc  bench.db
+date && ./bench-modernc-sqlx bench.db && ./bench-modernc-sqlx bench.db
+date && ./bench-modernc-sqlc bench.db && ./bench-modernc-sqlc bench.db
 date && ./bench-ncruces  bench.db  && ./bench-ncruces  bench.db
 date && ./bench-sqinn    bench.db  && ./bench-sqinn    bench.db
 date && ./bench-zombie   bench.db  && ./bench-zombie   bench.db